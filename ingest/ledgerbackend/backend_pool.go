@@ -0,0 +1,143 @@
+package ledgerbackend
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// BackendPool hands out up to a fixed number of ledger backends to concurrent
+// workers, reusing the backends returned to it instead of creating a new one
+// per worker. Backends are created on demand with the factory given to
+// NewBackendPool, which typically builds BufferedStorageBackends sharing a
+// single DataStore, so that the datastore client is established once.
+type BackendPool struct {
+	newBackend func() (LedgerBackend, error)
+
+	lock sync.Mutex
+	// created holds every backend created by the pool, whether idle or in use.
+	created []LedgerBackend
+	// inUse holds the backends handed out by Get and not returned yet.
+	inUse map[LedgerBackend]struct{}
+	// idle holds the backends available to Get. Its capacity is the size of
+	// the pool.
+	idle   chan LedgerBackend
+	closed bool
+	done   chan struct{}
+}
+
+// NewBackendPool returns a BackendPool holding at most size backends created
+// by newBackend.
+func NewBackendPool(size int, newBackend func() (LedgerBackend, error)) (*BackendPool, error) {
+	if size <= 0 {
+		return nil, errors.New("pool size must be > 0")
+	}
+	return &BackendPool{
+		newBackend: newBackend,
+		inUse:      map[LedgerBackend]struct{}{},
+		idle:       make(chan LedgerBackend, size),
+		done:       make(chan struct{}),
+	}, nil
+}
+
+// Get returns an idle backend, creating one if the pool is not full yet.
+// Otherwise it blocks until a backend is returned with Put, ctx is done or
+// the pool is closed. Backends must be returned with Put once the worker is
+// done with them.
+func (p *BackendPool) Get(ctx context.Context) (LedgerBackend, error) {
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		return nil, errors.New("BackendPool is closed; cannot Get")
+	}
+	select {
+	case backend := <-p.idle:
+		p.inUse[backend] = struct{}{}
+		p.lock.Unlock()
+		return backend, nil
+	default:
+	}
+	if len(p.created) < cap(p.idle) {
+		defer p.lock.Unlock()
+		backend, err := p.newBackend()
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating backend")
+		}
+		p.created = append(p.created, backend)
+		p.inUse[backend] = struct{}{}
+		return backend, nil
+	}
+	p.lock.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-p.done:
+		return nil, errors.New("BackendPool is closed; cannot Get")
+	case backend := <-p.idle:
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		if p.closed {
+			return nil, errors.New("BackendPool is closed; cannot Get")
+		}
+		p.inUse[backend] = struct{}{}
+		return backend, nil
+	}
+}
+
+// Put returns a backend obtained from Get to the pool. It returns an error if
+// the backend is not in use, i.e. it was not obtained from this pool or was
+// already returned. Backends returned after the pool is closed were already
+// closed by Close and are dropped.
+func (p *BackendPool) Put(backend LedgerBackend) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		return nil
+	}
+	if _, ok := p.inUse[backend]; !ok {
+		return errors.New("backend is not in use by the pool; cannot Put")
+	}
+	delete(p.inUse, backend)
+
+	select {
+	case p.idle <- backend:
+		return nil
+	default:
+	}
+	// idle only fills up if the pool is in an inconsistent state, drop the
+	// backend rather than blocking while holding the lock
+	for i, created := range p.created {
+		if created == backend {
+			p.created = append(p.created[:i], p.created[i+1:]...)
+			break
+		}
+	}
+	return errors.Wrap(backend.Close(), "error closing backend")
+}
+
+// Close closes every backend created by the pool, including the ones in use
+// by workers, and makes subsequent calls to Get fail. It returns the first
+// error returned by a backend, after attempting to close all of them.
+func (p *BackendPool) Close() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+
+	var firstErr error
+	for _, backend := range p.created {
+		if err := backend.Close(); err != nil && firstErr == nil {
+			firstErr = errors.Wrap(err, "error closing backend")
+		}
+	}
+	p.created = nil
+	p.inUse = nil
+	return firstErr
+}
@@ -0,0 +1,114 @@
+package ledgerbackend
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackendPool(t *testing.T) {
+	ctx := context.Background()
+	var backends []*MockDatabaseBackend
+	pool, err := NewBackendPool(2, func() (LedgerBackend, error) {
+		backend := &MockDatabaseBackend{}
+		backend.On("Close").Return(nil).Once()
+		backends = append(backends, backend)
+		return backend, nil
+	})
+	require.NoError(t, err)
+
+	first, err := pool.Get(ctx)
+	require.NoError(t, err)
+	second, err := pool.Get(ctx)
+	require.NoError(t, err)
+	assert.NotSame(t, first, second)
+
+	// the pool is full, Get waits for a backend to be returned
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = pool.Get(timeoutCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	received := make(chan LedgerBackend)
+	go func() {
+		backend, getErr := pool.Get(ctx)
+		assert.NoError(t, getErr)
+		received <- backend
+	}()
+	require.NoError(t, pool.Put(second))
+	assert.Same(t, second, <-received)
+
+	require.NoError(t, pool.Put(first))
+	reused, err := pool.Get(ctx)
+	require.NoError(t, err)
+	assert.Same(t, first, reused)
+	assert.Len(t, backends, 2)
+
+	// backends in use are closed too
+	require.NoError(t, pool.Close())
+	for _, backend := range backends {
+		backend.AssertExpectations(t)
+	}
+	assert.NoError(t, pool.Put(reused))
+	_, err = pool.Get(ctx)
+	assert.EqualError(t, err, "BackendPool is closed; cannot Get")
+	require.NoError(t, pool.Close())
+}
+
+func TestBackendPoolErrors(t *testing.T) {
+	ctx := context.Background()
+	_, err := NewBackendPool(0, nil)
+	assert.EqualError(t, err, "pool size must be > 0")
+
+	pool, err := NewBackendPool(1, func() (LedgerBackend, error) {
+		return nil, fmt.Errorf("no credentials")
+	})
+	require.NoError(t, err)
+	_, err = pool.Get(ctx)
+	assert.EqualError(t, err, "error creating backend: no credentials")
+	require.NoError(t, pool.Close())
+
+	// Close unblocks workers waiting for a backend
+	backend := &MockDatabaseBackend{}
+	backend.On("Close").Return(fmt.Errorf("boom")).Once()
+	pool, err = NewBackendPool(1, func() (LedgerBackend, error) { return backend, nil })
+	require.NoError(t, err)
+	_, err = pool.Get(ctx)
+	require.NoError(t, err)
+	waiting := make(chan error)
+	go func() {
+		_, getErr := pool.Get(ctx)
+		waiting <- getErr
+	}()
+	assert.EqualError(t, pool.Close(), "error closing backend: boom")
+	assert.EqualError(t, <-waiting, "BackendPool is closed; cannot Get")
+	backend.AssertExpectations(t)
+}
+
+func TestBackendPoolPutRejectsBackendsNotInUse(t *testing.T) {
+	ctx := context.Background()
+	pool, err := NewBackendPool(1, func() (LedgerBackend, error) {
+		backend := &MockDatabaseBackend{}
+		backend.On("Close").Return(nil).Once()
+		return backend, nil
+	})
+	require.NoError(t, err)
+
+	backend, err := pool.Get(ctx)
+	require.NoError(t, err)
+	require.NoError(t, pool.Put(backend))
+	// returning a backend twice or one the pool never handed out must not
+	// block on the full pool
+	assert.EqualError(t, pool.Put(backend), "backend is not in use by the pool; cannot Put")
+	assert.EqualError(t, pool.Put(&MockDatabaseBackend{}), "backend is not in use by the pool; cannot Put")
+
+	reused, err := pool.Get(ctx)
+	require.NoError(t, err)
+	assert.Same(t, backend, reused)
+	require.NoError(t, pool.Close())
+	backend.(*MockDatabaseBackend).AssertExpectations(t)
+}
@@ -5,6 +5,10 @@ package ledgerbackend
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -22,8 +26,71 @@ type BufferedStorageBackendConfig struct {
 	NumWorkers uint32        `toml:"num_workers"`
 	RetryLimit uint32        `toml:"retry_limit"`
 	RetryWait  time.Duration `toml:"retry_wait"`
+	// StrictLedgerWindow makes GetLedgerWindow return an error when the
+	// requested window extends past the available ledgers instead of
+	// clamping the window.
+	StrictLedgerWindow bool `toml:"strict_ledger_window"`
+	// VerifyPreparedRange makes IsPrepared also check that the objects
+	// containing the endpoints of the range exist in the datastore.
+	VerifyPreparedRange bool `toml:"verify_prepared_range"`
+	// VerifyBatchIntegrity makes the backend check that the ledgers in every
+	// downloaded batch are contiguous and match the batch boundaries, failing
+	// with ErrCorruptBatch otherwise.
+	VerifyBatchIntegrity bool `toml:"verify_batch_integrity"`
+	// ExpectedNetworkPassphrase, if set, is compared with the network passphrase
+	// recorded in the metadata of every downloaded object. A mismatch fails the
+	// prepared range with ErrNetworkMismatch. Each object is checked the first
+	// time it is downloaded only.
+	ExpectedNetworkPassphrase string `toml:"expected_network_passphrase"`
+	// MaxInFlightBytes, if set, caps the total size of the downloaded objects
+	// held in the buffer. Objects are buffered compressed, so the cap applies
+	// to their compressed size; the batch being decoded for the consumer is not
+	// counted. Workers wait for buffered objects to be consumed before adding
+	// an object which would exceed the cap, unless the buffer is empty or the
+	// object is the next one to be consumed.
+	MaxInFlightBytes uint64 `toml:"max_in_flight_bytes"`
+	// MaxRangeSize, if set, caps the number of ledgers GetLedgerWindow and
+	// VerifyChain accept in a single call, unless overridden for the call with
+	// WithMaxRangeSize. ForEachLedger is not limited as it streams ledgers
+	// without holding the range in memory.
+	MaxRangeSize uint32 `toml:"max_range_size"`
+	// TransformFunc, if set, is applied once to every ledger of a downloaded
+	// batch before the batch is cached and its ledgers are returned by
+	// GetLedger, e.g. to drop parts of the meta which are not needed. An
+	// error returned by TransformFunc fails the GetLedger call.
+	TransformFunc func(*xdr.LedgerCloseMeta) error `toml:"-"`
 }
 
+// ErrCorruptBatch is returned when VerifyBatchIntegrity is enabled and a
+// LedgerCloseMetaBatch contains duplicate, missing or out of order ledgers.
+var ErrCorruptBatch = errors.New("corrupt LedgerCloseMetaBatch")
+
+// ErrNetworkMismatch is returned when ExpectedNetworkPassphrase is set and an
+// object in the datastore was exported from a different network.
+var ErrNetworkMismatch = errors.New("datastore network does not match the expected network")
+
+// ErrReconfigured is returned by the calls interrupted by Reconfigure.
+var ErrReconfigured = errors.New("BufferedStorageBackend was reconfigured")
+
+// LedgerFetchError is returned by GetLedger and GetLedgerWithKey for every
+// failure, identifying the requested ledger and the datastore object holding it.
+type LedgerFetchError struct {
+	Sequence  uint32
+	ObjectKey string
+	Err       error
+}
+
+func (e *LedgerFetchError) Error() string {
+	return fmt.Sprintf("error getting ledger %d from %s: %v", e.Sequence, e.ObjectKey, e.Err)
+}
+
+func (e *LedgerFetchError) Unwrap() error {
+	return e.Err
+}
+
+// firstLedger is the first ledger of a network, ledger 1 is never exported.
+const firstLedger = uint32(2)
+
 // BufferedStorageBackend is a ledger backend that reads from a storage service.
 // The storage service contains files generated from the ledgerExporter.
 type BufferedStorageBackend struct {
@@ -34,6 +101,13 @@ type BufferedStorageBackend struct {
 	// ledgerBuffer is the buffer for LedgerCloseMeta data read in parallel.
 	ledgerBuffer *ledgerBuffer
 
+	// metrics are only set when the backend is decorated using WithMetrics
+	metrics bufferedStorageMetrics
+
+	// checkedKeys holds the keys of the objects whose network passphrase
+	// matched config.ExpectedNetworkPassphrase, it is replaced by Reconfigure.
+	checkedKeys *sync.Map
+
 	dataStore  datastore.DataStore
 	prepared   *Range // Non-nil if any range is prepared
 	closed     bool   // False until the core is closed
@@ -44,24 +118,75 @@ type BufferedStorageBackend struct {
 
 // NewBufferedStorageBackend returns a new BufferedStorageBackend instance.
 func NewBufferedStorageBackend(config BufferedStorageBackendConfig, dataStore datastore.DataStore) (*BufferedStorageBackend, error) {
+	if err := validateConfig(config, dataStore); err != nil {
+		return nil, err
+	}
+
+	bsBackend := &BufferedStorageBackend{
+		config:      config,
+		dataStore:   dataStore,
+		checkedKeys: &sync.Map{},
+	}
+
+	return bsBackend, nil
+}
+
+func validateConfig(config BufferedStorageBackendConfig, dataStore datastore.DataStore) error {
 	if config.BufferSize == 0 {
-		return nil, errors.New("buffer size must be > 0")
+		return errors.New("buffer size must be > 0")
 	}
 
 	if config.NumWorkers > config.BufferSize {
-		return nil, errors.New("number of workers must be <= BufferSize")
+		return errors.New("number of workers must be <= BufferSize")
 	}
 
 	if dataStore.GetSchema().LedgersPerFile <= 0 {
-		return nil, errors.New("ledgersPerFile must be > 0")
+		return errors.New("ledgersPerFile must be > 0")
 	}
 
-	bsBackend := &BufferedStorageBackend{
-		config:    config,
-		dataStore: dataStore,
+	return nil
+}
+
+// Reconfigure replaces the config and datastore of the BufferedStorageBackend, for
+// example to point it at a new bucket after a migration. The swap is atomic with
+// respect to GetLedger and the other methods: a call observes either the old or the
+// new configuration, never a mix of both. Any prepared range and buffered ledgers are
+// discarded, so PrepareRange must be called again before reading. Calls waiting
+// for ledgers which are not exported yet, e.g. when tailing an unbounded range,
+// fail with ErrReconfigured instead of delaying the swap.
+func (bsb *BufferedStorageBackend) Reconfigure(config BufferedStorageBackendConfig, dataStore datastore.DataStore) error {
+	if err := validateConfig(config, dataStore); err != nil {
+		return err
 	}
 
-	return bsBackend, nil
+	// GetLedger holds the read lock while it waits for the buffer, so the
+	// buffer must be cancelled before the write lock can be acquired.
+	bsb.bsBackendLock.RLock()
+	if bsb.ledgerBuffer != nil {
+		bsb.ledgerBuffer.cancel(ErrReconfigured)
+	}
+	bsb.bsBackendLock.RUnlock()
+
+	bsb.bsBackendLock.Lock()
+	defer bsb.bsBackendLock.Unlock()
+
+	if bsb.closed {
+		return errors.New("BufferedStorageBackend is closed; cannot Reconfigure")
+	}
+
+	if bsb.ledgerBuffer != nil {
+		bsb.ledgerBuffer.close()
+		bsb.ledgerBuffer = nil
+	}
+	bsb.config = config
+	bsb.dataStore = dataStore
+	bsb.checkedKeys = &sync.Map{}
+	bsb.prepared = nil
+	bsb.lcmBatch = xdr.LedgerCloseMetaBatch{}
+	bsb.nextLedger = 0
+	bsb.lastLedger = 0
+
+	return nil
 }
 
 // GetLatestLedgerSequence returns the most recent ledger sequence number available in the buffer.
@@ -100,11 +225,42 @@ func (bsb *BufferedStorageBackend) getBatchForSequence(ctx context.Context, sequ
 	}
 
 	// Sequence is beyond the current LedgerCloseMetaBatch
-	var err error
-	bsb.lcmBatch, err = bsb.ledgerBuffer.getFromLedgerQueue(ctx)
+	ledgerObject, err := bsb.ledgerBuffer.getFromLedgerQueue(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed getting next ledger batch from queue")
 	}
+	lcmBatch, err := bsb.ledgerBuffer.decodeObject(ledgerObject)
+	if err != nil {
+		return err
+	}
+	bsb.lcmBatch = lcmBatch
+	return nil
+}
+
+// verifyBatch checks that the ledgers in batch are strictly increasing, contiguous
+// and cover exactly [StartSequence, EndSequence].
+func verifyBatch(batch xdr.LedgerCloseMetaBatch) error {
+	start, end := uint32(batch.StartSequence), uint32(batch.EndSequence)
+	if end < start {
+		return errors.Wrapf(ErrCorruptBatch, "batch [%d,%d] ends before it starts", start, end)
+	}
+
+	for i, lcm := range batch.LedgerCloseMetas {
+		expected := start + uint32(i)
+		actual := LedgerSequence(lcm)
+		switch {
+		case i > 0 && actual == expected-1:
+			return errors.Wrapf(ErrCorruptBatch, "batch [%d,%d] contains ledger %d twice", start, end, actual)
+		case actual != expected:
+			return errors.Wrapf(ErrCorruptBatch, "batch [%d,%d] has ledger %d at index %d, expected %d",
+				start, end, actual, i, expected)
+		}
+	}
+
+	if count := uint32(len(batch.LedgerCloseMetas)); count != end-start+1 {
+		return errors.Wrapf(ErrCorruptBatch, "batch [%d,%d] contains %d ledgers, expected %d",
+			start, end, count, end-start+1)
+	}
 	return nil
 }
 
@@ -125,6 +281,162 @@ func (bsb *BufferedStorageBackend) GetLedger(ctx context.Context, sequence uint3
 	bsb.bsBackendLock.RLock()
 	defer bsb.bsBackendLock.RUnlock()
 
+	return bsb.getLedger(ctx, sequence)
+}
+
+// GetLedgerWithKey is like GetLedger but also returns the key of the datastore
+// object the ledger was read from.
+func (bsb *BufferedStorageBackend) GetLedgerWithKey(ctx context.Context, sequence uint32) (xdr.LedgerCloseMeta, string, error) {
+	bsb.bsBackendLock.RLock()
+	defer bsb.bsBackendLock.RUnlock()
+
+	ledgerCloseMeta, err := bsb.getLedger(ctx, sequence)
+	if err != nil {
+		return xdr.LedgerCloseMeta{}, "", err
+	}
+	return ledgerCloseMeta, bsb.dataStore.GetSchema().GetObjectKeyFromSequenceNumber(sequence), nil
+}
+
+// GetLedgerBatch returns the whole LedgerCloseMetaBatch stored in the object
+// containing the given ledger. The object is read directly from the datastore,
+// so GetLedgerBatch neither requires nor affects a prepared range, but it goes
+// through the same checks, metrics and TransformFunc as the buffered ledgers.
+// While it is decoded, the object counts towards the MaxInFlightBytes of the
+// prepared range, if any.
+func (bsb *BufferedStorageBackend) GetLedgerBatch(ctx context.Context, sequence uint32) (xdr.LedgerCloseMetaBatch, error) {
+	reader, err := bsb.snapshot("GetLedgerBatch")
+	if err != nil {
+		return xdr.LedgerCloseMetaBatch{}, err
+	}
+	return bsb.getLedgerBatch(ctx, reader, sequence)
+}
+
+// getLedgerBatch is GetLedgerBatch reading through reader, a snapshot of the
+// config and datastore.
+func (bsb *BufferedStorageBackend) getLedgerBatch(ctx context.Context, reader objectReader, sequence uint32) (xdr.LedgerCloseMetaBatch, error) {
+	objectKey := reader.dataStore.GetSchema().GetObjectKeyFromSequenceNumber(sequence)
+	ledgerObject, err := reader.downloadLedgerObject(ctx, sequence)
+	if err != nil {
+		return xdr.LedgerCloseMetaBatch{}, err
+	}
+	defer bsb.holdBytes(ledgerObject)()
+
+	batch, err := reader.decodeObject(ledgerObject)
+	if err != nil {
+		return xdr.LedgerCloseMetaBatch{}, errors.Wrapf(err, "unable to decode file: %s", objectKey)
+	}
+	if sequence < uint32(batch.StartSequence) || sequence > uint32(batch.EndSequence) {
+		return xdr.LedgerCloseMetaBatch{}, errors.Errorf("file %s holds batch [%d,%d] which does not contain ledger %d",
+			objectKey, batch.StartSequence, batch.EndSequence, sequence)
+	}
+	return batch, nil
+}
+
+// snapshot returns an objectReader over the current config and datastore, so
+// that methods reading the datastore without holding bsBackendLock use a
+// consistent configuration even if Reconfigure is called meanwhile.
+func (bsb *BufferedStorageBackend) snapshot(operation string) (objectReader, error) {
+	bsb.bsBackendLock.RLock()
+	defer bsb.bsBackendLock.RUnlock()
+
+	if bsb.closed {
+		return objectReader{}, errors.Errorf("BufferedStorageBackend is closed; cannot %s", operation)
+	}
+	return bsb.objectReader(), nil
+}
+
+// holdBytes accounts for an object read outside of the prepared range in the
+// ledger buffer, if any, until the returned function is called.
+func (bsb *BufferedStorageBackend) holdBytes(ledgerObject []byte) (release func()) {
+	bsb.bsBackendLock.RLock()
+	defer bsb.bsBackendLock.RUnlock()
+
+	if bsb.ledgerBuffer == nil {
+		return func() {}
+	}
+	return bsb.ledgerBuffer.holdBytes(ledgerObject)
+}
+
+// GetLedgersBySequences returns the requested ledgers, which need not be
+// contiguous, keyed by sequence. Like GetLedgerBatch it reads the datastore
+// directly, neither requiring nor affecting a prepared range, and downloads
+// each object holding one of the sequences once. Duplicate sequences are
+// ignored. It fails if any of the ledgers is missing from the datastore.
+func (bsb *BufferedStorageBackend) GetLedgersBySequences(ctx context.Context, sequences []uint32) (map[uint32]xdr.LedgerCloseMeta, error) {
+	reader, err := bsb.snapshot("GetLedgersBySequences")
+	if err != nil {
+		return nil, err
+	}
+	schema := reader.dataStore.GetSchema()
+	// group the sequences by object, keeping the objects in request order
+	var objects []uint32
+	sequencesByObject := map[string][]uint32{}
+	for _, sequence := range sequences {
+		objectKey := schema.GetObjectKeyFromSequenceNumber(sequence)
+		if _, ok := sequencesByObject[objectKey]; !ok {
+			objects = append(objects, sequence)
+		}
+		sequencesByObject[objectKey] = append(sequencesByObject[objectKey], sequence)
+	}
+
+	ledgers := make(map[uint32]xdr.LedgerCloseMeta, len(sequences))
+	for _, objectSequence := range objects {
+		batch, err := bsb.getLedgerBatch(ctx, reader, objectSequence)
+		if err != nil {
+			return nil, err
+		}
+		for _, sequence := range sequencesByObject[schema.GetObjectKeyFromSequenceNumber(objectSequence)] {
+			if ledgers[sequence], err = batch.GetLedger(sequence); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ledgers, nil
+}
+
+// GetLedgerTransactionResults returns the results of the transactions applied
+// in the given ledger, without the rest of the ledger meta.
+func (bsb *BufferedStorageBackend) GetLedgerTransactionResults(ctx context.Context, sequence uint32) ([]xdr.TransactionResultPair, error) {
+	ledgerCloseMeta, err := bsb.GetLedger(ctx, sequence)
+	if err != nil {
+		return nil, err
+	}
+	return TransactionResults(ledgerCloseMeta)
+}
+
+// GetLedgerUpgrades returns the network upgrades applied in the given ledger,
+// without the rest of the ledger meta.
+func (bsb *BufferedStorageBackend) GetLedgerUpgrades(ctx context.Context, sequence uint32) ([]xdr.UpgradeEntryMeta, error) {
+	ledgerCloseMeta, err := bsb.GetLedger(ctx, sequence)
+	if err != nil {
+		return nil, err
+	}
+	return Upgrades(ledgerCloseMeta)
+}
+
+// GetLedgerContractEvents returns the contract events emitted in the given
+// ledger, without the rest of the ledger meta.
+func (bsb *BufferedStorageBackend) GetLedgerContractEvents(ctx context.Context, sequence uint32) ([]xdr.ContractEvent, error) {
+	ledgerCloseMeta, err := bsb.GetLedger(ctx, sequence)
+	if err != nil {
+		return nil, err
+	}
+	return ContractEvents(ledgerCloseMeta)
+}
+
+func (bsb *BufferedStorageBackend) getLedger(ctx context.Context, sequence uint32) (xdr.LedgerCloseMeta, error) {
+	ledgerCloseMeta, err := bsb.fetchLedger(ctx, sequence)
+	if err != nil {
+		return xdr.LedgerCloseMeta{}, &LedgerFetchError{
+			Sequence:  sequence,
+			ObjectKey: bsb.dataStore.GetSchema().GetObjectKeyFromSequenceNumber(sequence),
+			Err:       err,
+		}
+	}
+	return ledgerCloseMeta, nil
+}
+
+func (bsb *BufferedStorageBackend) fetchLedger(ctx context.Context, sequence uint32) (xdr.LedgerCloseMeta, error) {
 	if bsb.closed {
 		return xdr.LedgerCloseMeta{}, errors.New("BufferedStorageBackend is closed; cannot GetLedger")
 	}
@@ -160,12 +472,411 @@ func (bsb *BufferedStorageBackend) GetLedger(ctx context.Context, sequence uint3
 	if err != nil {
 		return xdr.LedgerCloseMeta{}, err
 	}
+	// Catch batches whose contents do not match their header
+	if actual := LedgerSequence(ledgerCloseMeta); actual != sequence {
+		return xdr.LedgerCloseMeta{}, errors.Errorf("requested ledger %d but batch [%d,%d] returned ledger %d",
+			sequence, bsb.lcmBatch.StartSequence, bsb.lcmBatch.EndSequence, actual)
+	}
 	bsb.lastLedger = bsb.nextLedger
 	bsb.nextLedger++
 
 	return ledgerCloseMeta, nil
 }
 
+// ForEachLedger prepares the given range and invokes fn for every ledger in it,
+// in ascending order. Each object in the datastore is downloaded once, so memory
+// usage stays flat regardless of the size of the range.
+// Iteration stops at the first error returned by fn, which is returned as is.
+// Unbounded ranges are iterated until ctx is done.
+// The deadline of ctx applies to the whole iteration: once it is exceeded no
+// further ledger is read and the error reports how many ledgers were fetched.
+// If the backend was already read from, or is prepared for a range which does
+// not cover ledgerRange, the previously prepared range is discarded.
+func (bsb *BufferedStorageBackend) ForEachLedger(ctx context.Context, ledgerRange Range, fn func(xdr.LedgerCloseMeta) error) error {
+	bsb.bsBackendLock.RLock()
+	resumable := bsb.prepared == nil ||
+		(ledgerRange.from == bsb.nextExpectedSequence() && bsb.isPrepared(ledgerRange))
+	bsb.bsBackendLock.RUnlock()
+	if !resumable {
+		if err := bsb.reset(); err != nil {
+			return err
+		}
+	}
+	if err := bsb.PrepareRange(ctx, ledgerRange); err != nil {
+		return err
+	}
+
+	for sequence := ledgerRange.from; !ledgerRange.bounded || sequence <= ledgerRange.to; sequence++ {
+		err := ctx.Err()
+		var ledgerCloseMeta xdr.LedgerCloseMeta
+		if err == nil {
+			ledgerCloseMeta, err = bsb.GetLedger(ctx, sequence)
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return errors.Wrapf(err, "deadline exceeded mid-range %s after fetching %d ledgers",
+				ledgerRange, sequence-ledgerRange.from)
+		} else if err != nil {
+			return err
+		}
+		if err = fn(ledgerCloseMeta); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ScanPartition invokes fn for every ledger stored in the partition starting at
+// partitionStart, in ascending order. The objects of the partition are read
+// directly from the datastore one after the other and each is decoded once, so
+// ScanPartition neither requires nor affects a prepared range. The scan ends at
+// the first object missing from the datastore if none of the following objects
+// of the partition exist, which allows scanning the partition currently being
+// exported. It returns an error wrapping os.ErrNotExist if the partition is
+// empty or if an object is missing before the last one stored.
+// Iteration stops at the first error returned by fn, which is returned as is,
+// or when ctx is done.
+func (bsb *BufferedStorageBackend) ScanPartition(ctx context.Context, partitionStart uint32, fn func(xdr.LedgerCloseMeta) error) error {
+	reader, err := bsb.snapshot("ScanPartition")
+	if err != nil {
+		return err
+	}
+	schema := reader.dataStore.GetSchema()
+	partitionSize, err := checkPartitionStart(schema, partitionStart)
+	if err != nil {
+		return err
+	}
+
+	// Ledgers before 2 are never exported, so the first partition starts
+	// with the object containing ledger 2.
+	sequence := max(partitionStart, 2)
+	for sequence-partitionStart < partitionSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		batch, err := bsb.getLedgerBatch(ctx, reader, sequence)
+		if errors.Is(err, os.ErrNotExist) && sequence > max(partitionStart, 2) {
+			return checkTrailingObjects(ctx, reader, sequence, partitionStart+(partitionSize-1), err)
+		} else if err != nil {
+			return err
+		}
+		for _, ledgerCloseMeta := range batch.LedgerCloseMetas {
+			if err = fn(ledgerCloseMeta); err != nil {
+				return err
+			}
+		}
+		sequence = schema.GetSequenceNumberEndBoundary(sequence) + 1
+		if sequence == 0 {
+			// the object ended at math.MaxUint32
+			return nil
+		}
+	}
+	return nil
+}
+
+// checkTrailingObjects checks that the objects from the one containing sequence,
+// which is missing with notExistErr, up to the one containing partitionEnd are
+// all missing, returning notExistErr if any of them exists.
+func checkTrailingObjects(ctx context.Context, reader objectReader, sequence, partitionEnd uint32, notExistErr error) error {
+	schema := reader.dataStore.GetSchema()
+	for next := schema.GetSequenceNumberEndBoundary(sequence) + 1; next != 0 && next <= partitionEnd; {
+		objectKey := schema.GetObjectKeyFromSequenceNumber(next)
+		exists, err := reader.dataStore.Exists(ctx, objectKey)
+		if err != nil {
+			return errors.Wrapf(err, "error checking existence of ledger %d", next)
+		}
+		if exists {
+			return errors.Wrapf(notExistErr, "ledger %d is missing before file %s", sequence, objectKey)
+		}
+		next = schema.GetSequenceNumberEndBoundary(next) + 1
+	}
+	return nil
+}
+
+// checkPartitionStart returns the number of ledgers in each partition of the
+// given schema, failing if partitionStart is not the first ledger of one.
+func checkPartitionStart(schema datastore.DataStoreSchema, partitionStart uint32) (uint32, error) {
+	if schema.LedgersPerFile == 0 {
+		return 0, errors.New("datastore schema has no ledgers per file")
+	}
+	start, end := datastore.PartitionForSequence(partitionStart, schema.LedgersPerFile, schema.FilesPerPartition)
+	partitionSize := end - start + 1
+	if start != partitionStart {
+		return 0, errors.Errorf("%d is not the start of a partition of %d ledgers", partitionStart, partitionSize)
+	}
+	return partitionSize, nil
+}
+
+// PartitionTimeRange returns the close times of the first and last ledgers
+// stored in the partition starting at partitionStart. Only the first and last
+// objects of the partition are downloaded; the last one is located with
+// existence checks, assuming objects are exported in order, so the partition
+// currently being exported is bracketed up to its latest object.
+// It returns an error wrapping os.ErrNotExist if the partition is empty.
+func (bsb *BufferedStorageBackend) PartitionTimeRange(ctx context.Context, partitionStart uint32) (start, end time.Time, err error) {
+	reader, err := bsb.snapshot("PartitionTimeRange")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	schema := reader.dataStore.GetSchema()
+	partitionSize, err := checkPartitionStart(schema, partitionStart)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	// Ledgers before 2 are never exported, so the first partition starts
+	// with the object containing ledger 2.
+	firstSequence := max(partitionStart, 2)
+	if start, err = bsb.batchCloseTime(ctx, reader, firstSequence, false); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	// Binary search the last object of the partition among the ones
+	// following the first.
+	firstEnd := uint64(schema.GetSequenceNumberEndBoundary(firstSequence))
+	partitionEnd := min(uint64(partitionStart)+uint64(partitionSize)-1, math.MaxUint32)
+	objectStart := func(i int) uint32 {
+		return uint32(firstEnd + uint64(i)*uint64(schema.LedgersPerFile) + 1)
+	}
+	var existsErr error
+	following := sort.Search(int((partitionEnd-firstEnd)/uint64(schema.LedgersPerFile)), func(i int) bool {
+		if existsErr != nil {
+			return true
+		}
+		exists, err := reader.dataStore.Exists(ctx, schema.GetObjectKeyFromSequenceNumber(objectStart(i)))
+		if err != nil {
+			existsErr = errors.Wrapf(err, "error checking existence of ledger %d", objectStart(i))
+		}
+		return !exists
+	})
+	if existsErr != nil {
+		return time.Time{}, time.Time{}, existsErr
+	}
+
+	lastSequence := firstSequence
+	if following > 0 {
+		lastSequence = objectStart(following - 1)
+	}
+	if end, err = bsb.batchCloseTime(ctx, reader, lastSequence, true); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, end, nil
+}
+
+// batchCloseTime returns the close time of the first, or last, ledger of the
+// object containing the given sequence.
+func (bsb *BufferedStorageBackend) batchCloseTime(ctx context.Context, reader objectReader, sequence uint32, last bool) (time.Time, error) {
+	batch, err := bsb.getLedgerBatch(ctx, reader, sequence)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(batch.LedgerCloseMetas) == 0 {
+		return time.Time{}, errors.Errorf("batch [%d,%d] has no ledgers", batch.StartSequence, batch.EndSequence)
+	}
+	if last {
+		return CloseTime(batch.LedgerCloseMetas[len(batch.LedgerCloseMetas)-1])
+	}
+	return CloseTime(batch.LedgerCloseMetas[0])
+}
+
+// RangeStats returns the number of objects holding the ledgers of a bounded
+// range and their total size in bytes, without downloading them. It fails if
+// any of the objects is missing from the datastore.
+func (bsb *BufferedStorageBackend) RangeStats(ctx context.Context, ledgerRange Range) (objectCount int, totalBytes int64, err error) {
+	if err := requireBounded("RangeStats", ledgerRange); err != nil {
+		return 0, 0, err
+	}
+
+	bsb.bsBackendLock.RLock()
+	defer bsb.bsBackendLock.RUnlock()
+
+	if bsb.closed {
+		return 0, 0, errors.New("BufferedStorageBackend is closed; cannot RangeStats")
+	}
+
+	schema := bsb.dataStore.GetSchema()
+	for sequence := schema.GetSequenceNumberStartBoundary(ledgerRange.from); sequence <= ledgerRange.to; {
+		objectKey := schema.GetObjectKeyFromSequenceNumber(sequence)
+		size, err := bsb.dataStore.Size(ctx, objectKey)
+		if err != nil {
+			return 0, 0, errors.Wrapf(err, "unable to get size of file: %s", objectKey)
+		}
+		objectCount++
+		totalBytes += size
+
+		sequence = schema.GetSequenceNumberEndBoundary(sequence) + 1
+		if sequence == 0 {
+			// the object ended at math.MaxUint32
+			break
+		}
+	}
+	return objectCount, totalBytes, nil
+}
+
+// VerifyChain checks that the ledgers in the given bounded range are linked,
+// i.e. that the previous ledger hash of every ledger header matches the hash of
+// the ledger preceding it. Verification stops at the first broken link and the
+// returned error names the offending sequence.
+// The link between ledgerRange.From() and its predecessor is not checked.
+// VerifyChain prepares ledgerRange like ForEachLedger, discarding any previously
+// prepared range, so PrepareRange must be called again before resuming reads
+// with GetLedger.
+func (bsb *BufferedStorageBackend) VerifyChain(ctx context.Context, ledgerRange Range, opts ...RangeOption) error {
+	if err := requireBounded("VerifyChain", ledgerRange); err != nil {
+		return err
+	}
+	reader, err := bsb.snapshot("VerifyChain")
+	if err != nil {
+		return err
+	}
+	if err = checkRangeSize(reader.config, "VerifyChain", ledgerRange, opts); err != nil {
+		return err
+	}
+
+	var previous *xdr.LedgerHeaderHistoryEntry
+	return bsb.ForEachLedger(ctx, ledgerRange, func(lcm xdr.LedgerCloseMeta) error {
+		header, err := ledgerHeader(lcm)
+		if err != nil {
+			return err
+		}
+		if previous != nil && header.Header.PreviousLedgerHash != previous.Hash {
+			return errors.Errorf(
+				"ledger %d previous ledger hash %s does not match hash %s of ledger %d",
+				header.Header.LedgerSeq,
+				header.Header.PreviousLedgerHash.HexString(),
+				previous.Hash.HexString(),
+				previous.Header.LedgerSeq,
+			)
+		}
+		previous = &header
+		return nil
+	})
+}
+
+// RangeOption customizes a single call to VerifyChain or GetLedgerWindow.
+type RangeOption func(*rangeOptions)
+
+type rangeOptions struct {
+	maxRangeSize *uint32
+}
+
+// WithMaxRangeSize overrides config.MaxRangeSize for a single call, e.g. for
+// a one-off verification of a range known to be large. 0 disables the limit.
+func WithMaxRangeSize(maxRangeSize uint32) RangeOption {
+	return func(options *rangeOptions) {
+		options.maxRangeSize = &maxRangeSize
+	}
+}
+
+// checkRangeSize returns an error if ledgerRange holds more than
+// config.MaxRangeSize ledgers, or the limit set by opts.
+func checkRangeSize(config BufferedStorageBackendConfig, operation string, ledgerRange Range, opts []RangeOption) error {
+	var options rangeOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	maxRangeSize := config.MaxRangeSize
+	if options.maxRangeSize != nil {
+		maxRangeSize = *options.maxRangeSize
+	}
+	// Count is 0 for [0,math.MaxUint32], which exceeds any limit
+	if count := ledgerRange.Count(); maxRangeSize > 0 && (count == 0 || count > maxRangeSize) {
+		return errors.Errorf("%s range %s exceeds the maximum range size of %d ledgers", operation, ledgerRange, maxRangeSize)
+	}
+	return nil
+}
+
+// GetLedgerWindow returns the ledgers in [sequence-before, sequence+after].
+// Neighbours stored in the same object are decoded from a single download.
+// The window is clamped to the first ledger of the network and to the last
+// object present in the datastore, unless config.StrictLedgerWindow is set,
+// in which case a window extending past them is an error.
+// GetLedgerWindow discards any previously prepared range, so PrepareRange must
+// be called again before resuming reads with GetLedger.
+func (bsb *BufferedStorageBackend) GetLedgerWindow(ctx context.Context, sequence, before, after uint32, opts ...RangeOption) ([]xdr.LedgerCloseMeta, error) {
+	if sequence < firstLedger {
+		return nil, errors.Errorf("requested sequence %d precedes the first ledger %d", sequence, firstLedger)
+	}
+	reader, err := bsb.snapshot("GetLedgerWindow")
+	if err != nil {
+		return nil, err
+	}
+
+	from := firstLedger
+	if sequence-firstLedger >= before {
+		from = sequence - before
+	} else if reader.config.StrictLedgerWindow {
+		return nil, errors.Errorf("ledger window [%d-%d,%d+%d] precedes the first ledger %d", sequence, before, sequence, after, firstLedger)
+	}
+
+	to := uint32(math.MaxUint32)
+	if math.MaxUint32-sequence >= after {
+		to = sequence + after
+	}
+	if err := checkRangeSize(reader.config, "GetLedgerWindow", BoundedRange(from, to), opts); err != nil {
+		return nil, err
+	}
+
+	schema := reader.dataStore.GetSchema()
+	sequenceStart := schema.GetSequenceNumberStartBoundary(sequence)
+	for to > sequence {
+		toStart := schema.GetSequenceNumberStartBoundary(to)
+		if toStart <= sequenceStart {
+			// to is in the object containing sequence, which must exist
+			break
+		}
+		exists, err := reader.dataStore.Exists(ctx, schema.GetObjectKeyFromSequenceNumber(to))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error checking existence of ledger %d", to)
+		}
+		if exists {
+			break
+		}
+		if reader.config.StrictLedgerWindow {
+			return nil, errors.Errorf("ledger %d in window [%d-%d,%d+%d] is not available", to, sequence, before, sequence, after)
+		}
+		// Move to the last ledger of the previous object, toStart > 0 as it
+		// follows the object containing sequence
+		to = max(toStart-1, sequence)
+	}
+
+	if err := bsb.reset(); err != nil {
+		return nil, err
+	}
+
+	ledgers := make([]xdr.LedgerCloseMeta, 0, to-from+1)
+	err = bsb.ForEachLedger(ctx, BoundedRange(from, to), func(lcm xdr.LedgerCloseMeta) error {
+		ledgers = append(ledgers, lcm)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ledgers, nil
+}
+
+// reset discards the prepared range, if any, so that a new range can be
+// prepared regardless of how far the previous one was consumed.
+func (bsb *BufferedStorageBackend) reset() error {
+	bsb.bsBackendLock.Lock()
+	defer bsb.bsBackendLock.Unlock()
+
+	if bsb.closed {
+		return errors.New("BufferedStorageBackend is closed; cannot reset")
+	}
+
+	if bsb.ledgerBuffer != nil {
+		bsb.ledgerBuffer.close()
+	}
+	bsb.prepared = nil
+	bsb.lcmBatch = xdr.LedgerCloseMetaBatch{}
+	bsb.nextLedger = 0
+	bsb.lastLedger = 0
+
+	return nil
+}
+
 // PrepareRange checks if the starting and ending (if bounded) ledgers exist.
 func (bsb *BufferedStorageBackend) PrepareRange(ctx context.Context, ledgerRange Range) error {
 	bsb.bsBackendLock.Lock()
@@ -195,7 +906,40 @@ func (bsb *BufferedStorageBackend) IsPrepared(ctx context.Context, ledgerRange R
 		return false, errors.New("BufferedStorageBackend is closed; cannot IsPrepared")
 	}
 
-	return bsb.isPrepared(ledgerRange), nil
+	if !bsb.isPrepared(ledgerRange) {
+		return false, nil
+	}
+
+	if bsb.config.VerifyPreparedRange {
+		return bsb.rangeEndpointsExist(ctx, ledgerRange)
+	}
+
+	return true, nil
+}
+
+// rangeEndpointsExist checks that the objects containing the start and, if bounded,
+// the end of the range are present in the datastore. Each object is checked
+// once, so a range within a single object, such as a single-ledger range,
+// costs one existence check.
+func (bsb *BufferedStorageBackend) rangeEndpointsExist(ctx context.Context, ledgerRange Range) (bool, error) {
+	schema := bsb.dataStore.GetSchema()
+	endpoints := []uint32{ledgerRange.from}
+	if ledgerRange.bounded &&
+		schema.GetObjectKeyFromSequenceNumber(ledgerRange.to) != schema.GetObjectKeyFromSequenceNumber(ledgerRange.from) {
+		endpoints = append(endpoints, ledgerRange.to)
+	}
+
+	for _, sequence := range endpoints {
+		exists, err := bsb.dataStore.Exists(ctx, schema.GetObjectKeyFromSequenceNumber(sequence))
+		if err != nil {
+			return false, errors.Wrapf(err, "error checking existence of ledger %d", sequence)
+		}
+		if !exists {
+			return false, nil
+		}
+	}
+
+	return true, nil
 }
 
 func (bsb *BufferedStorageBackend) isPrepared(ledgerRange Range) bool {
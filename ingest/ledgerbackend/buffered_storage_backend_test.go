@@ -1,19 +1,24 @@
 package ledgerbackend
 
 import (
+	"archive/tar"
 	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"math"
 	"os"
+	"path/filepath"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
+	"github.com/stellar/go/ingest/ledgerbackend/ledgerbackendtest"
 	"github.com/stellar/go/support/compressxdr"
 	"github.com/stellar/go/support/datastore"
 	"github.com/stellar/go/xdr"
@@ -121,6 +126,30 @@ func createLCMBatchReader(start, end, count uint32) io.ReadCloser {
 	return io.NopCloser(reader)
 }
 
+func createFakeDataStore(t testing.TB, start, end, count uint32) *ledgerbackendtest.FakeDataStore {
+	schema := datastore.DataStoreSchema{
+		LedgersPerFile:    count,
+		FilesPerPartition: partitionSize,
+	}
+	fakeDataStore := ledgerbackendtest.NewFakeDataStore(schema)
+	for i := start; i <= end; i = i + count {
+		contents, err := io.ReadAll(createLCMBatchReader(i, i+count-1, count))
+		require.NoError(t, err)
+		fakeDataStore.SetFile(schema.GetObjectKeyFromSequenceNumber(i), contents)
+	}
+	return fakeDataStore
+}
+
+// requireLedgerFetchError asserts that err is a LedgerFetchError for sequence
+// in the given datastore, caused by an error with the given message.
+func requireLedgerFetchError(t *testing.T, err error, dataStore datastore.DataStore, sequence uint32, cause string) {
+	var fetchErr *LedgerFetchError
+	require.ErrorAs(t, err, &fetchErr)
+	assert.Equal(t, sequence, fetchErr.Sequence)
+	assert.Equal(t, dataStore.GetSchema().GetObjectKeyFromSequenceNumber(sequence), fetchErr.ObjectKey)
+	assert.EqualError(t, fetchErr.Err, cause)
+}
+
 func TestNewBufferedStorageBackend(t *testing.T) {
 	config := createBufferedStorageBackendConfigForTesting()
 	mockDataStore := new(datastore.MockDataStore)
@@ -250,15 +279,18 @@ func TestBSBGetLedger_ErrorPreceedingLedger(t *testing.T) {
 	assert.Equal(t, lcmArray[0], lcm)
 
 	_, err = bsb.GetLedger(ctx, uint32(2))
-	assert.EqualError(t, err, "requested sequence preceeds current LedgerRange")
+	requireLedgerFetchError(t, err, mockDataStore, 2, "requested sequence preceeds current LedgerRange")
 }
 
 func TestBSBGetLedger_NotPrepared(t *testing.T) {
 	bsb := createBufferedStorageBackendForTesting()
+	bsb.dataStore = createFakeDataStore(t, 2, 5, ledgerPerFileCount)
 	ctx := context.Background()
 
 	_, err := bsb.GetLedger(ctx, uint32(3))
-	assert.EqualError(t, err, "session is not prepared, call PrepareRange first")
+	requireLedgerFetchError(t, err, bsb.dataStore, 3, "session is not prepared, call PrepareRange first")
+	assert.EqualError(t, err, "error getting ledger 3 from FFFFFFFF--0-63999/FFFFFFFC--3.xdr.zstd: "+
+		"session is not prepared, call PrepareRange first")
 }
 
 func TestBSBGetLedger_SequenceNotInBatch(t *testing.T) {
@@ -275,10 +307,161 @@ func TestBSBGetLedger_SequenceNotInBatch(t *testing.T) {
 	assert.Eventually(t, func() bool { return len(bsb.ledgerBuffer.ledgerQueue) == 3 }, time.Second*5, time.Millisecond*50)
 
 	_, err := bsb.GetLedger(ctx, uint32(2))
-	assert.EqualError(t, err, "requested sequence preceeds current LedgerRange")
+	requireLedgerFetchError(t, err, mockDataStore, 2, "requested sequence preceeds current LedgerRange")
 
 	_, err = bsb.GetLedger(ctx, uint32(6))
-	assert.EqualError(t, err, "requested sequence beyond current LedgerRange")
+	requireLedgerFetchError(t, err, mockDataStore, 6, "requested sequence beyond current LedgerRange")
+}
+
+func TestBSBGetLedgerWithKey(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.dataStore = createFakeDataStore(t, 2, 5, 2)
+	schema := bsb.dataStore.GetSchema()
+
+	assert.NoError(t, bsb.PrepareRange(ctx, BoundedRange(2, 5)))
+	for sequence := uint32(2); sequence <= 5; sequence++ {
+		lcm, key, err := bsb.GetLedgerWithKey(ctx, sequence)
+		assert.NoError(t, err)
+		assert.Equal(t, sequence, lcm.LedgerSequence())
+		assert.Equal(t, schema.GetObjectKeyFromSequenceNumber(sequence), key)
+	}
+
+	_, key, err := bsb.GetLedgerWithKey(ctx, 7)
+	assert.Error(t, err)
+	assert.Empty(t, key)
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBGetLedgerBatch(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	fakeDataStore := createFakeDataStore(t, 0, 15, 8)
+	bsb.dataStore = fakeDataStore
+
+	for _, sequence := range []uint32{8, 11, 15} {
+		batch, err := bsb.GetLedgerBatch(ctx, sequence)
+		require.NoError(t, err)
+		assert.Equal(t, createTestLedgerCloseMetaBatch(8, 15, 8), batch)
+		assert.LessOrEqual(t, uint32(batch.StartSequence), sequence)
+		assert.GreaterOrEqual(t, uint32(batch.EndSequence), sequence)
+	}
+
+	// the object containing ledger 16 is not in the datastore
+	_, err := bsb.GetLedgerBatch(ctx, 16)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// a batch which does not contain the requested ledger is rejected
+	var buf bytes.Buffer
+	_, err = compressxdr.NewXDREncoder(compressxdr.DefaultCompressor, createTestLedgerCloseMetaBatch(24, 31, 8)).WriteTo(&buf)
+	require.NoError(t, err)
+	fakeDataStore.SetFile(fakeDataStore.GetSchema().GetObjectKeyFromSequenceNumber(16), buf.Bytes())
+	_, err = bsb.GetLedgerBatch(ctx, 16)
+	assert.EqualError(t, err, "file FFFFFFFF--0-511999/FFFFFFEF--16-23.xdr.zstd holds batch [24,31] which does not contain ledger 16")
+
+	assert.NoError(t, bsb.Close())
+	_, err = bsb.GetLedgerBatch(ctx, 8)
+	assert.EqualError(t, err, "BufferedStorageBackend is closed; cannot GetLedgerBatch")
+}
+
+func TestBSBGetLedger_SequenceMismatch(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	fakeDataStore := createFakeDataStore(t, 2, 3, 2)
+	bsb.dataStore = fakeDataStore
+
+	// the header claims [2,3] but the batch holds ledgers 10 and 11
+	batch := createTestLedgerCloseMetaBatch(10, 11, 2)
+	batch.StartSequence = 2
+	batch.EndSequence = 3
+	var buf bytes.Buffer
+	_, err := compressxdr.NewXDREncoder(compressxdr.DefaultCompressor, batch).WriteTo(&buf)
+	assert.NoError(t, err)
+	fakeDataStore.SetFile(fakeDataStore.GetSchema().GetObjectKeyFromSequenceNumber(2), buf.Bytes())
+
+	assert.NoError(t, bsb.PrepareRange(ctx, BoundedRange(2, 3)))
+	_, err = bsb.GetLedger(ctx, 2)
+	requireLedgerFetchError(t, err, fakeDataStore, 2, "requested ledger 2 but batch [2,3] returned ledger 10")
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBGetLedger_TruncatedBatch(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	fakeDataStore := createFakeDataStore(t, 2, 5, 4)
+	bsb.dataStore = fakeDataStore
+
+	// the header claims [2,5] but the last ledger is missing
+	batch := createTestLedgerCloseMetaBatch(2, 5, 4)
+	batch.LedgerCloseMetas = batch.LedgerCloseMetas[:3]
+	var buf bytes.Buffer
+	_, err := compressxdr.NewXDREncoder(compressxdr.DefaultCompressor, batch).WriteTo(&buf)
+	require.NoError(t, err)
+	fakeDataStore.SetFile(fakeDataStore.GetSchema().GetObjectKeyFromSequenceNumber(2), buf.Bytes())
+
+	require.NoError(t, bsb.PrepareRange(ctx, BoundedRange(2, 5)))
+	for sequence := uint32(2); sequence <= 4; sequence++ {
+		_, err = bsb.GetLedger(ctx, sequence)
+		require.NoError(t, err)
+	}
+	_, err = bsb.GetLedger(ctx, 5)
+	requireLedgerFetchError(t, err, fakeDataStore, 5, "LedgerCloseMeta for sequence 5 not found in the batch [2, 5] holding 3 ledgers")
+	assert.ErrorIs(t, err, xdr.ErrLedgerNotInBatch)
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBGetLedger_BatchStartsAfterSequence(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	fakeDataStore := createFakeDataStore(t, 2, 5, 4)
+	bsb.dataStore = fakeDataStore
+
+	// the object for [2,5] holds the batch of the following object
+	contents, err := io.ReadAll(createLCMBatchReader(6, 9, 4))
+	require.NoError(t, err)
+	fakeDataStore.SetFile(fakeDataStore.GetSchema().GetObjectKeyFromSequenceNumber(2), contents)
+
+	require.NoError(t, bsb.PrepareRange(ctx, BoundedRange(2, 5)))
+	_, err = bsb.GetLedger(ctx, 2)
+	requireLedgerFetchError(t, err, fakeDataStore, 2,
+		"ledger sequence 2 is outside the valid range of ledger sequences [6, 9] this batch holds")
+	assert.ErrorIs(t, err, xdr.ErrLedgerNotInBatch)
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBGetLedger_VerifyBatchIntegrity(t *testing.T) {
+	for _, testCase := range []struct {
+		name      string
+		sequences []uint32
+		err       string
+	}{
+		{"duplicate", []uint32{2, 3, 3, 5}, "batch [2,5] contains ledger 3 twice: corrupt LedgerCloseMetaBatch"},
+		{"gap", []uint32{2, 3, 5, 6}, "batch [2,5] has ledger 5 at index 2, expected 4: corrupt LedgerCloseMetaBatch"},
+		{"missing", []uint32{2, 3, 4}, "batch [2,5] contains 3 ledgers, expected 4: corrupt LedgerCloseMetaBatch"},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			bsb := createBufferedStorageBackendForTesting()
+			bsb.config.VerifyBatchIntegrity = true
+			fakeDataStore := createFakeDataStore(t, 2, 5, 4)
+			bsb.dataStore = fakeDataStore
+
+			batch := xdr.LedgerCloseMetaBatch{StartSequence: 2, EndSequence: 5}
+			for _, sequence := range testCase.sequences {
+				batch.LedgerCloseMetas = append(batch.LedgerCloseMetas, createLedgerCloseMeta(sequence))
+			}
+			var buf bytes.Buffer
+			_, err := compressxdr.NewXDREncoder(compressxdr.DefaultCompressor, batch).WriteTo(&buf)
+			require.NoError(t, err)
+			fakeDataStore.SetFile(fakeDataStore.GetSchema().GetObjectKeyFromSequenceNumber(2), buf.Bytes())
+
+			require.NoError(t, bsb.PrepareRange(ctx, BoundedRange(2, 5)))
+			_, err = bsb.GetLedger(ctx, 2)
+			assert.ErrorIs(t, err, ErrCorruptBatch)
+			requireLedgerFetchError(t, err, fakeDataStore, 2, testCase.err)
+			assert.NoError(t, bsb.Close())
+		})
+	}
 }
 
 func TestBSBPrepareRange(t *testing.T) {
@@ -367,6 +550,323 @@ func TestBSBIsPrepared_Unbounded(t *testing.T) {
 	assert.False(t, ok)
 }
 
+func TestBSBIsPrepared_VerifyPreparedRange(t *testing.T) {
+	ctx := context.Background()
+	for _, verify := range []bool{false, true} {
+		bsb := createBufferedStorageBackendForTesting()
+		bsb.config.VerifyPreparedRange = verify
+		bsb.dataStore = createFakeDataStore(t, 2, 5, ledgerPerFileCount)
+
+		assert.NoError(t, bsb.PrepareRange(ctx, UnboundedRange(2)))
+
+		ok, err := bsb.IsPrepared(ctx, BoundedRange(2, 5))
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		// the object containing ledger 9 is not in the datastore
+		ok, err = bsb.IsPrepared(ctx, BoundedRange(2, 9))
+		assert.NoError(t, err)
+		assert.Equal(t, !verify, ok)
+
+		assert.NoError(t, bsb.Close())
+	}
+}
+
+type existsCountingDataStore struct {
+	datastore.DataStore
+	existsCalls int
+}
+
+func (d *existsCountingDataStore) Exists(ctx context.Context, path string) (bool, error) {
+	d.existsCalls++
+	return d.DataStore.Exists(ctx, path)
+}
+
+func TestBSBIsPrepared_VerifyPreparedRange_SingleObject(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.config.VerifyPreparedRange = true
+	dataStore := &existsCountingDataStore{DataStore: createFakeDataStore(t, 2, 9, 2)}
+	bsb.dataStore = dataStore
+
+	assert.NoError(t, bsb.PrepareRange(ctx, BoundedRange(3, 3)))
+	ok, err := bsb.IsPrepared(ctx, BoundedRange(3, 3))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, dataStore.existsCalls)
+
+	// both ledgers of [4,5] are stored in the same object
+	assert.NoError(t, bsb.PrepareRange(ctx, BoundedRange(4, 5)))
+	ok, err = bsb.IsPrepared(ctx, BoundedRange(4, 5))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, dataStore.existsCalls)
+
+	assert.NoError(t, bsb.PrepareRange(ctx, BoundedRange(4, 7)))
+	ok, err = bsb.IsPrepared(ctx, BoundedRange(4, 7))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 4, dataStore.existsCalls)
+
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBReconfigure(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.dataStore = createFakeDataStore(t, 2, 9, 2)
+	assert.NoError(t, bsb.PrepareRange(ctx, UnboundedRange(2)))
+
+	migrated := createFakeDataStore(t, 2, 9, 1)
+	config := createBufferedStorageBackendConfigForTesting()
+	config.NumWorkers = 1
+	assert.EqualError(t, bsb.Reconfigure(BufferedStorageBackendConfig{}, migrated), "buffer size must be > 0")
+
+	assert.NoError(t, bsb.Reconfigure(config, migrated))
+	_, err := bsb.GetLedger(ctx, 2)
+	requireLedgerFetchError(t, err, migrated, 2, "session is not prepared, call PrepareRange first")
+
+	assert.NoError(t, bsb.PrepareRange(ctx, BoundedRange(4, 5)))
+	lcm, err := bsb.GetLedger(ctx, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(4), lcm.LedgerSequence())
+	assert.Equal(t, 1, migrated.Calls(migrated.GetSchema().GetObjectKeyFromSequenceNumber(4)))
+	assert.Equal(t, uint32(1), bsb.config.NumWorkers)
+
+	assert.NoError(t, bsb.Close())
+	assert.EqualError(t, bsb.Reconfigure(config, migrated), "BufferedStorageBackend is closed; cannot Reconfigure")
+}
+
+func TestBSBReconfigure_WhileTailing(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.dataStore = createFakeDataStore(t, 2, 5, 1)
+	require.NoError(t, bsb.PrepareRange(ctx, UnboundedRange(2)))
+	for sequence := uint32(2); sequence <= 5; sequence++ {
+		_, err := bsb.GetLedger(ctx, sequence)
+		require.NoError(t, err)
+	}
+
+	// ledger 6 is not exported yet, GetLedger waits for it
+	tailErr := make(chan error, 1)
+	go func() {
+		_, err := bsb.GetLedger(ctx, 6)
+		tailErr <- err
+	}()
+	select {
+	case err := <-tailErr:
+		require.FailNow(t, "GetLedger returned before ledger 6 was exported", "error: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	migrated := createFakeDataStore(t, 2, 9, 1)
+	reconfigured := make(chan error, 1)
+	go func() {
+		reconfigured <- bsb.Reconfigure(createBufferedStorageBackendConfigForTesting(), migrated)
+	}()
+	select {
+	case err := <-reconfigured:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		require.FailNow(t, "Reconfigure blocked by GetLedger waiting on an unbounded range")
+	}
+	assert.ErrorIs(t, <-tailErr, ErrReconfigured)
+
+	require.NoError(t, bsb.PrepareRange(ctx, UnboundedRange(6)))
+	lcm, err := bsb.GetLedger(ctx, 6)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(6), lcm.LedgerSequence())
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBReconfigure_ConcurrentReads(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	stores := []datastore.DataStore{
+		createFakeDataStore(t, 0, 99, 2),
+		createFakeDataStore(t, 0, 99, 5),
+	}
+	bsb.dataStore = stores[0]
+	require.NoError(t, bsb.PrepareRange(ctx, BoundedRange(2, 99)))
+
+	done := make(chan struct{})
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(readErr)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			// Reads may fail while the backend is not prepared, but a successful
+			// read must always return the requested ledger.
+			for sequence := uint32(2); sequence <= 99; sequence++ {
+				lcm, err := bsb.GetLedger(ctx, sequence)
+				if err != nil {
+					break
+				}
+				if lcm.LedgerSequence() != sequence {
+					readErr <- fmt.Errorf("requested ledger %d but got %d", sequence, lcm.LedgerSequence())
+					return
+				}
+			}
+		}
+	}()
+
+	// methods reading the datastore directly each work with a snapshot of the
+	// configuration, so they succeed regardless of Reconfigure
+	directReadErr := make(chan error, 1)
+	go func() {
+		defer close(directReadErr)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if err := checkDirectReads(ctx, &bsb); err != nil {
+				directReadErr <- err
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, bsb.Reconfigure(createBufferedStorageBackendConfigForTesting(), stores[i%2]))
+		require.NoError(t, bsb.PrepareRange(ctx, BoundedRange(2, 99)))
+		time.Sleep(time.Millisecond)
+	}
+	close(done)
+
+	assert.NoError(t, <-readErr)
+	assert.NoError(t, <-directReadErr)
+	assert.NoError(t, bsb.Close())
+}
+
+// checkDirectReads reads ledgers [2,99] with the methods which do not use
+// the prepared range, checking that each returns the requested ledgers.
+func checkDirectReads(ctx context.Context, bsb *BufferedStorageBackend) error {
+	ledgers, err := bsb.GetLedgersBySequences(ctx, []uint32{2, 50, 99})
+	if err != nil {
+		return err
+	}
+	for _, sequence := range []uint32{2, 50, 99} {
+		if actual := ledgers[sequence].LedgerSequence(); actual != sequence {
+			return fmt.Errorf("requested ledger %d but got %d", sequence, actual)
+		}
+	}
+
+	// the first object may hold ledgers before 2
+	next := uint32(0)
+	err = bsb.ScanPartition(ctx, 0, func(lcm xdr.LedgerCloseMeta) error {
+		if next == 0 && lcm.LedgerSequence() <= 2 {
+			next = lcm.LedgerSequence()
+		}
+		if lcm.LedgerSequence() != next {
+			return fmt.Errorf("expected ledger %d but scanned %d", next, lcm.LedgerSequence())
+		}
+		next++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if next != 100 {
+		return fmt.Errorf("scanned ledgers up to %d instead of 99", next-1)
+	}
+
+	if _, _, err = bsb.PartitionTimeRange(ctx, 0); err != nil {
+		return err
+	}
+	return nil
+}
+
+func TestBSBReconfigure_ConcurrentWindows(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	stores := []datastore.DataStore{
+		createFakeDataStore(t, 0, 99, 2),
+		createFakeDataStore(t, 0, 99, 5),
+	}
+	bsb.dataStore = stores[0]
+
+	done := make(chan struct{})
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(readErr)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			// Reconfigure interrupts the range prepared by GetLedgerWindow and
+			// VerifyChain, but a successful call must return the requested ledgers.
+			window, err := bsb.GetLedgerWindow(ctx, 50, 2, 2, WithMaxRangeSize(10))
+			if err == nil {
+				for i, lcm := range window {
+					if lcm.LedgerSequence() != uint32(48+i) {
+						readErr <- fmt.Errorf("window ledger %d is %d", 48+i, lcm.LedgerSequence())
+						return
+					}
+				}
+			}
+			_ = bsb.VerifyChain(ctx, BoundedRange(2, 20))
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		config := createBufferedStorageBackendConfigForTesting()
+		config.MaxRangeSize = uint32(20 + i)
+		config.StrictLedgerWindow = i%2 == 0
+		require.NoError(t, bsb.Reconfigure(config, stores[i%2]))
+		time.Sleep(time.Millisecond)
+	}
+	close(done)
+
+	assert.NoError(t, <-readErr)
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBMetrics(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.dataStore = createFakeDataStore(t, 2, 3, 2)
+	registry := prometheus.NewRegistry()
+	backend := WithMetrics(&bsb, registry, "test")
+
+	assert.NoError(t, backend.PrepareRange(ctx, BoundedRange(2, 3)))
+	_, err := backend.GetLedger(ctx, 2)
+	assert.NoError(t, err)
+
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+	summaries := map[string]uint64{}
+	counters := map[string]float64{}
+	for _, family := range families {
+		if counter := family.GetMetric()[0].GetCounter(); counter != nil {
+			counters[family.GetName()] = counter.GetValue()
+			continue
+		}
+		summary := family.GetMetric()[0].GetSummary()
+		assert.GreaterOrEqual(t, summary.GetSampleSum(), float64(0))
+		summaries[family.GetName()] = summary.GetSampleCount()
+	}
+	compressed := counters["test_ingest_buffered_storage_backend_compressed_bytes_total"]
+	decompressed := counters["test_ingest_buffered_storage_backend_decompressed_bytes_total"]
+	assert.Greater(t, compressed, float64(0))
+	assert.GreaterOrEqual(t, decompressed, compressed)
+	for _, name := range []string{
+		"test_ingest_buffered_storage_backend_download_duration_seconds",
+		"test_ingest_buffered_storage_backend_decompress_duration_seconds",
+		"test_ingest_buffered_storage_backend_unmarshal_duration_seconds",
+	} {
+		assert.Equal(t, uint64(1), summaries[name], name)
+	}
+	assert.NoError(t, backend.Close())
+}
+
 func TestBSBClose(t *testing.T) {
 	startLedger := uint32(2)
 	endLedger := uint32(3)
@@ -388,7 +888,7 @@ func TestBSBClose(t *testing.T) {
 	assert.EqualError(t, err, "BufferedStorageBackend is closed; cannot GetLatestLedgerSequence")
 
 	_, err = bsb.GetLedger(ctx, 3)
-	assert.EqualError(t, err, "BufferedStorageBackend is closed; cannot GetLedger")
+	requireLedgerFetchError(t, err, mockDataStore, 3, "BufferedStorageBackend is closed; cannot GetLedger")
 
 	err = bsb.PrepareRange(ctx, ledgerRange)
 	assert.EqualError(t, err, "BufferedStorageBackend is closed; cannot PrepareRange")
@@ -443,6 +943,53 @@ func TestLedgerBufferInvariant(t *testing.T) {
 	assert.Equal(t, 0, len(bsb.ledgerBuffer.ledgerQueue))
 }
 
+func TestLedgerBufferMaxInFlightBytes(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.config.NumWorkers = 1
+	bsb.config.BufferSize = 10
+	fakeDataStore := createFakeDataStore(t, 0, 79, 8)
+	bsb.dataStore = fakeDataStore
+
+	// leave room for the first three objects only
+	for sequence := uint32(0); sequence < 24; sequence += 8 {
+		size, err := fakeDataStore.Size(ctx, fakeDataStore.GetSchema().GetObjectKeyFromSequenceNumber(sequence))
+		require.NoError(t, err)
+		bsb.config.MaxInFlightBytes += uint64(size)
+	}
+	inFlightBytes := func() uint64 {
+		bsb.ledgerBuffer.bytesLock.Lock()
+		defer bsb.ledgerBuffer.bytesLock.Unlock()
+		return bsb.ledgerBuffer.inFlightBytes
+	}
+
+	require.NoError(t, bsb.PrepareRange(ctx, BoundedRange(2, 79)))
+	assert.Eventually(t, func() bool { return len(bsb.ledgerBuffer.ledgerQueue) == 3 }, time.Second*5, time.Millisecond*10)
+	assert.Never(t, func() bool { return len(bsb.ledgerBuffer.ledgerQueue) > 3 }, time.Millisecond*50, time.Millisecond*10)
+	assert.Equal(t, bsb.config.MaxInFlightBytes, inFlightBytes())
+
+	for sequence := uint32(2); sequence <= 79; sequence++ {
+		lcm, err := bsb.GetLedger(ctx, sequence)
+		require.NoError(t, err)
+		require.Equal(t, sequence, lcm.LedgerSequence())
+		require.LessOrEqual(t, inFlightBytes(), bsb.config.MaxInFlightBytes)
+	}
+	assert.Equal(t, uint64(0), inFlightBytes())
+	assert.NoError(t, bsb.Close())
+
+	// out of order downloads filling the cap do not block the consumer
+	bsb = createBufferedStorageBackendForTesting()
+	bsb.config.NumWorkers = 5
+	bsb.config.BufferSize = 10
+	bsb.config.MaxInFlightBytes = 1
+	bsb.dataStore = fakeDataStore
+	fakeDataStore.SetLatency(fakeDataStore.GetSchema().GetObjectKeyFromSequenceNumber(2), time.Millisecond*20)
+	ledgers, err := bsb.GetLedgerWindow(ctx, 2, 0, 77)
+	require.NoError(t, err)
+	assert.Equal(t, createLCMForTesting(2, 79), ledgers)
+	assert.NoError(t, bsb.Close())
+}
+
 func TestLedgerBufferClose(t *testing.T) {
 	ctx := context.Background()
 	bsb := createBufferedStorageBackendForTesting()
@@ -476,7 +1023,7 @@ func TestLedgerBufferClose(t *testing.T) {
 	bsb.ledgerBuffer.wg.Wait()
 
 	_, err := bsb.GetLedger(ctx, 3)
-	assert.EqualError(t, err, "failed getting next ledger batch from queue: context canceled")
+	requireLedgerFetchError(t, err, mockDataStore, 3, "failed getting next ledger batch from queue: context canceled")
 }
 
 func TestLedgerBufferBoundedObjectNotFound(t *testing.T) {
@@ -541,11 +1088,46 @@ func TestLedgerBufferUnboundedObjectNotFound(t *testing.T) {
 	assert.NoError(t, bsb.PrepareRange(ctx, ledgerRange))
 
 	_, err := bsb.GetLedger(ctx, 3)
-	assert.EqualError(t, err, "failed getting next ledger batch from queue: context canceled")
+	requireLedgerFetchError(t, err, mockDataStore, 3, "failed getting next ledger batch from queue: context canceled")
 	assert.GreaterOrEqual(t, iteration.Load(), cancelAfter)
 	assert.NoError(t, bsb.Close())
 }
 
+func TestBSBExpectedNetworkPassphrase(t *testing.T) {
+	ctx := context.Background()
+	schema := datastore.DataStoreSchema{LedgersPerFile: 2, FilesPerPartition: partitionSize}
+	fakeDataStore := ledgerbackendtest.NewFakeDataStore(schema)
+	for _, object := range []struct {
+		start      uint32
+		passphrase string
+	}{
+		{2, "Test SDF Network ; September 2015"},
+		{4, "Public Global Stellar Network ; September 2015"},
+	} {
+		contents, err := io.ReadAll(createLCMBatchReader(object.start, object.start+1, 2))
+		require.NoError(t, err)
+		metaData := datastore.MetaData{NetworkPassPhrase: object.passphrase}
+		require.NoError(t, fakeDataStore.PutFile(ctx, schema.GetObjectKeyFromSequenceNumber(object.start),
+			bytes.NewReader(contents), metaData.ToMap()))
+	}
+
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.config.ExpectedNetworkPassphrase = "Test SDF Network ; September 2015"
+	bsb.dataStore = fakeDataStore
+
+	assert.NoError(t, bsb.PrepareRange(ctx, BoundedRange(2, 5)))
+	for sequence := uint32(2); sequence <= 3; sequence++ {
+		lcm, err := bsb.GetLedger(ctx, sequence)
+		assert.NoError(t, err)
+		assert.Equal(t, sequence, lcm.LedgerSequence())
+	}
+
+	_, err := bsb.GetLedger(ctx, 4)
+	assert.ErrorIs(t, err, ErrNetworkMismatch)
+	assert.ErrorContains(t, err, `was exported from network "Public Global Stellar Network ; September 2015", expected "Test SDF Network ; September 2015"`)
+	assert.NoError(t, bsb.Close())
+}
+
 func TestLedgerBufferRetryLimit(t *testing.T) {
 	bsb := createBufferedStorageBackendForTesting()
 	bsb.config.NumWorkers = 1
@@ -578,3 +1160,722 @@ func TestLedgerBufferRetryLimit(t *testing.T) {
 	assert.ErrorContains(t, err, objectName)
 	assert.ErrorContains(t, err, "transient error")
 }
+
+func TestBSBForEachLedger(t *testing.T) {
+	startLedger := uint32(2)
+	endLedger := uint32(7)
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	fakeDataStore := createFakeDataStore(t, startLedger, endLedger, 2)
+	bsb.dataStore = fakeDataStore
+
+	var sequences []uint32
+	err := bsb.ForEachLedger(ctx, BoundedRange(startLedger, endLedger), func(lcm xdr.LedgerCloseMeta) error {
+		sequences = append(sequences, lcm.LedgerSequence())
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{2, 3, 4, 5, 6, 7}, sequences)
+	assert.Equal(t, 3, fakeDataStore.TotalCalls())
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBForEachLedger_AlreadyConsumed(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.dataStore = createFakeDataStore(t, 2, 40, 1)
+
+	require.NoError(t, bsb.PrepareRange(ctx, BoundedRange(2, 40)))
+	for sequence := uint32(2); sequence <= 10; sequence++ {
+		_, err := bsb.GetLedger(ctx, sequence)
+		require.NoError(t, err)
+	}
+
+	collect := func(ledgerRange Range) []uint32 {
+		var sequences []uint32
+		require.NoError(t, bsb.ForEachLedger(ctx, ledgerRange, func(lcm xdr.LedgerCloseMeta) error {
+			sequences = append(sequences, lcm.LedgerSequence())
+			return nil
+		}))
+		return sequences
+	}
+	// ahead of, behind and right after the ledgers already read
+	assert.Equal(t, []uint32{20, 21, 22, 23, 24, 25}, collect(BoundedRange(20, 25)))
+	assert.Equal(t, []uint32{3, 4, 5}, collect(BoundedRange(3, 5)))
+	assert.Equal(t, []uint32{6, 7}, collect(BoundedRange(6, 7)))
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBForEachLedger_UnboundedRange(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.dataStore = createFakeDataStore(t, 2, 9, 2)
+
+	// streaming accepts unbounded ranges, until fn or ctx stops it
+	stopErr := fmt.Errorf("stop")
+	var sequences []uint32
+	err := bsb.ForEachLedger(ctx, UnboundedRange(2), func(lcm xdr.LedgerCloseMeta) error {
+		sequences = append(sequences, lcm.LedgerSequence())
+		if len(sequences) == 5 {
+			return stopErr
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, stopErr)
+	assert.Equal(t, []uint32{2, 3, 4, 5, 6}, sequences)
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBForEachLedger_StopsOnError(t *testing.T) {
+	startLedger := uint32(2)
+	endLedger := uint32(7)
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.dataStore = createFakeDataStore(t, startLedger, endLedger, 2)
+
+	stopErr := fmt.Errorf("stop")
+	var sequences []uint32
+	err := bsb.ForEachLedger(ctx, BoundedRange(startLedger, endLedger), func(lcm xdr.LedgerCloseMeta) error {
+		sequences = append(sequences, lcm.LedgerSequence())
+		if lcm.LedgerSequence() == 4 {
+			return stopErr
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, stopErr)
+	assert.Equal(t, []uint32{2, 3, 4}, sequences)
+	assert.NoError(t, bsb.Close())
+}
+
+// BenchmarkBSBGetLedger compares reading ledgers from objects holding a single
+// ledger with reading them from objects holding a batch of ledgers.
+func BenchmarkBSBGetLedger(b *testing.B) {
+	const ledgerCount = 1024
+	for _, ledgersPerFile := range []uint32{1, 64} {
+		b.Run(fmt.Sprintf("ledgers_per_file=%d", ledgersPerFile), func(b *testing.B) {
+			ledgerRange := BoundedRange(64, 64+ledgerCount-1)
+			ctx := context.Background()
+			bsb := createBufferedStorageBackendForTesting()
+			// buffer the whole range so that the objects are fetched up front
+			bsb.config.BufferSize = ledgerCount
+			bsb.dataStore = createFakeDataStore(b, ledgerRange.from, ledgerRange.to, ledgersPerFile)
+			stopErr := fmt.Errorf("stop")
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for read := 0; read < b.N; {
+				require.NoError(b, bsb.reset())
+				err := bsb.ForEachLedger(ctx, ledgerRange, func(xdr.LedgerCloseMeta) error {
+					if read++; read == b.N {
+						return stopErr
+					}
+					return nil
+				})
+				if err != stopErr {
+					require.NoError(b, err)
+				}
+			}
+			b.StopTimer()
+			require.NoError(b, bsb.Close())
+		})
+	}
+}
+
+func TestBSBScanPartition(t *testing.T) {
+	ctx := context.Background()
+	schema := datastore.DataStoreSchema{LedgersPerFile: 2, FilesPerPartition: 4}
+	fakeDataStore := ledgerbackendtest.NewFakeDataStore(schema)
+	// partitions [0,7] and [8,15] are complete, [16,23] is being exported
+	for i := uint32(2); i <= 19; i += 2 {
+		contents, err := io.ReadAll(createLCMBatchReader(i, i+1, 2))
+		require.NoError(t, err)
+		fakeDataStore.SetFile(schema.GetObjectKeyFromSequenceNumber(i), contents)
+	}
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.dataStore = fakeDataStore
+
+	scan := func(partitionStart uint32) []uint32 {
+		var sequences []uint32
+		require.NoError(t, bsb.ScanPartition(ctx, partitionStart, func(lcm xdr.LedgerCloseMeta) error {
+			sequences = append(sequences, lcm.LedgerSequence())
+			return nil
+		}))
+		return sequences
+	}
+	assert.Equal(t, []uint32{2, 3, 4, 5, 6, 7}, scan(0))
+	assert.Equal(t, []uint32{8, 9, 10, 11, 12, 13, 14, 15}, scan(8))
+	assert.Equal(t, []uint32{16, 17, 18, 19}, scan(16))
+	// each object is read once
+	assert.Equal(t, 1, fakeDataStore.Calls(schema.GetObjectKeyFromSequenceNumber(10)))
+
+	stopErr := fmt.Errorf("stop")
+	var sequences []uint32
+	err := bsb.ScanPartition(ctx, 8, func(lcm xdr.LedgerCloseMeta) error {
+		sequences = append(sequences, lcm.LedgerSequence())
+		if lcm.LedgerSequence() == 10 {
+			return stopErr
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, stopErr)
+	assert.Equal(t, []uint32{8, 9, 10}, sequences)
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	err = bsb.ScanPartition(cancelledCtx, 8, func(xdr.LedgerCloseMeta) error { return nil })
+	assert.ErrorIs(t, err, context.Canceled)
+
+	err = bsb.ScanPartition(ctx, 4, func(xdr.LedgerCloseMeta) error { return nil })
+	assert.EqualError(t, err, "4 is not the start of a partition of 8 ledgers")
+
+	// an empty partition is not scanned successfully
+	err = bsb.ScanPartition(ctx, 24, func(xdr.LedgerCloseMeta) error { return nil })
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBScanPartition_MissingObject(t *testing.T) {
+	ctx := context.Background()
+	schema := datastore.DataStoreSchema{LedgersPerFile: 2, FilesPerPartition: 4}
+	fakeDataStore := ledgerbackendtest.NewFakeDataStore(schema)
+	// partition [8,15] misses the object holding [10,11]
+	for _, i := range []uint32{8, 12, 14} {
+		contents, err := io.ReadAll(createLCMBatchReader(i, i+1, 2))
+		require.NoError(t, err)
+		fakeDataStore.SetFile(schema.GetObjectKeyFromSequenceNumber(i), contents)
+	}
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.dataStore = fakeDataStore
+
+	var sequences []uint32
+	err := bsb.ScanPartition(ctx, 8, func(lcm xdr.LedgerCloseMeta) error {
+		sequences = append(sequences, lcm.LedgerSequence())
+		return nil
+	})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.ErrorContains(t, err, "ledger 10 is missing before file "+schema.GetObjectKeyFromSequenceNumber(12))
+	assert.Equal(t, []uint32{8, 9}, sequences)
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBPartitionTimeRange(t *testing.T) {
+	ctx := context.Background()
+	schema := datastore.DataStoreSchema{LedgersPerFile: 2, FilesPerPartition: 4}
+	fakeDataStore := ledgerbackendtest.NewFakeDataStore(schema)
+	// partitions [0,7] and [8,15] are complete, [16,23] is being exported.
+	// Ledger i closes at 1700000000 + 5*i.
+	for i := uint32(2); i <= 19; i += 2 {
+		batch := createTestLedgerCloseMetaBatch(i, i+1, 2)
+		for _, lcm := range batch.LedgerCloseMetas {
+			lcm.V0.LedgerHeader.Header.ScpValue.CloseTime = xdr.TimePoint(1700000000 + 5*lcm.LedgerSequence())
+		}
+		var buf bytes.Buffer
+		_, err := compressxdr.NewXDREncoder(compressxdr.DefaultCompressor, batch).WriteTo(&buf)
+		require.NoError(t, err)
+		fakeDataStore.SetFile(schema.GetObjectKeyFromSequenceNumber(i), buf.Bytes())
+	}
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.dataStore = fakeDataStore
+	closeTime := func(sequence uint32) time.Time {
+		return time.Unix(int64(1700000000+5*sequence), 0).UTC()
+	}
+
+	for _, testCase := range []struct {
+		partitionStart uint32
+		first, last    uint32
+	}{
+		{0, 2, 7},
+		{8, 8, 15},
+		{16, 16, 19},
+	} {
+		start, end, err := bsb.PartitionTimeRange(ctx, testCase.partitionStart)
+		require.NoError(t, err)
+		assert.Equal(t, closeTime(testCase.first), start)
+		assert.Equal(t, closeTime(testCase.last), end)
+	}
+	// only the first and last objects are downloaded
+	assert.Zero(t, fakeDataStore.Calls(schema.GetObjectKeyFromSequenceNumber(10)))
+	assert.Equal(t, 1, fakeDataStore.Calls(schema.GetObjectKeyFromSequenceNumber(14)))
+
+	_, _, err := bsb.PartitionTimeRange(ctx, 24)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, _, err = bsb.PartitionTimeRange(ctx, 4)
+	assert.EqualError(t, err, "4 is not the start of a partition of 8 ledgers")
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBGetLedgersBySequences(t *testing.T) {
+	ctx := context.Background()
+	fakeDataStore := createFakeDataStore(t, 0, 31, 8)
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.dataStore = fakeDataStore
+	schema := fakeDataStore.GetSchema()
+
+	ledgers, err := bsb.GetLedgersBySequences(ctx, []uint32{30, 3, 9, 5, 30, 3})
+	require.NoError(t, err)
+	assert.Equal(t, map[uint32]xdr.LedgerCloseMeta{
+		3:  createLedgerCloseMeta(3),
+		5:  createLedgerCloseMeta(5),
+		9:  createLedgerCloseMeta(9),
+		30: createLedgerCloseMeta(30),
+	}, ledgers)
+	// each object holding a requested ledger is downloaded once
+	assert.Equal(t, 1, fakeDataStore.Calls(schema.GetObjectKeyFromSequenceNumber(3)))
+	assert.Equal(t, 1, fakeDataStore.Calls(schema.GetObjectKeyFromSequenceNumber(9)))
+	assert.Equal(t, 1, fakeDataStore.Calls(schema.GetObjectKeyFromSequenceNumber(30)))
+	assert.Equal(t, 3, fakeDataStore.TotalCalls())
+
+	ledgers, err = bsb.GetLedgersBySequences(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, ledgers)
+
+	_, err = bsb.GetLedgersBySequences(ctx, []uint32{4, 50})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBTransformFunc(t *testing.T) {
+	ctx := context.Background()
+	transformed := map[uint32]int{}
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.dataStore = createFakeDataStore(t, 0, 11, 4)
+	bsb.config.TransformFunc = func(lcm *xdr.LedgerCloseMeta) error {
+		transformed[lcm.LedgerSequence()]++
+		lcm.V0.TxSet = xdr.TransactionSet{PreviousLedgerHash: xdr.Hash{1}}
+		return nil
+	}
+	expected := func(sequence uint32) xdr.LedgerCloseMeta {
+		lcm := createLedgerCloseMeta(sequence)
+		lcm.V0.TxSet = xdr.TransactionSet{PreviousLedgerHash: xdr.Hash{1}}
+		return lcm
+	}
+
+	require.NoError(t, bsb.PrepareRange(ctx, BoundedRange(3, 9)))
+	for sequence := uint32(3); sequence <= 9; sequence++ {
+		lcm, err := bsb.GetLedger(ctx, sequence)
+		require.NoError(t, err)
+		assert.Equal(t, expected(sequence), lcm)
+		if sequence == 5 {
+			// the cached batch was already transformed
+			lcm, err = bsb.GetLedger(ctx, sequence)
+			require.NoError(t, err)
+			assert.Equal(t, expected(sequence), lcm)
+		}
+	}
+	// ledgers are transformed once, including the ones of a batch which lie
+	// outside the prepared range
+	assert.Equal(t, map[uint32]int{0: 1, 1: 1, 2: 1, 3: 1, 4: 1, 5: 1, 6: 1, 7: 1, 8: 1, 9: 1, 10: 1, 11: 1}, transformed)
+	assert.NoError(t, bsb.Close())
+
+	bsb = createBufferedStorageBackendForTesting()
+	bsb.dataStore = createFakeDataStore(t, 0, 11, 4)
+	bsb.config.TransformFunc = func(lcm *xdr.LedgerCloseMeta) error {
+		if lcm.LedgerSequence() == 7 {
+			return fmt.Errorf("boom")
+		}
+		return nil
+	}
+	require.NoError(t, bsb.PrepareRange(ctx, BoundedRange(2, 9)))
+	for sequence := uint32(2); sequence <= 3; sequence++ {
+		_, err := bsb.GetLedger(ctx, sequence)
+		require.NoError(t, err)
+	}
+	_, err := bsb.GetLedger(ctx, 4)
+	assert.EqualError(t, err, "error getting ledger 4 from "+
+		bsb.dataStore.GetSchema().GetObjectKeyFromSequenceNumber(4)+": error transforming ledger 7: boom")
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBExpectedNetworkPassphrase_ChecksObjectsOnce(t *testing.T) {
+	ctx := context.Background()
+	schema := datastore.DataStoreSchema{LedgersPerFile: 2, FilesPerPartition: partitionSize}
+	fakeDataStore := ledgerbackendtest.NewFakeDataStore(schema)
+	for _, start := range []uint32{2, 4} {
+		contents, err := io.ReadAll(createLCMBatchReader(start, start+1, 2))
+		require.NoError(t, err)
+		metaData := datastore.MetaData{NetworkPassPhrase: "Test SDF Network ; September 2015"}
+		require.NoError(t, fakeDataStore.PutFile(ctx, schema.GetObjectKeyFromSequenceNumber(start),
+			bytes.NewReader(contents), metaData.ToMap()))
+	}
+	config := createBufferedStorageBackendConfigForTesting()
+	config.ExpectedNetworkPassphrase = "Test SDF Network ; September 2015"
+	bsb, err := NewBufferedStorageBackend(config, fakeDataStore)
+	require.NoError(t, err)
+	registry := prometheus.NewRegistry()
+	WithMetrics(bsb, registry, "test")
+	sampleCounts := func() (downloads, networkChecks uint64) {
+		families, err := registry.Gather()
+		require.NoError(t, err)
+		for _, family := range families {
+			switch family.GetName() {
+			case "test_ingest_buffered_storage_backend_download_duration_seconds":
+				downloads = family.GetMetric()[0].GetSummary().GetSampleCount()
+			case "test_ingest_buffered_storage_backend_network_check_duration_seconds":
+				networkChecks = family.GetMetric()[0].GetSummary().GetSampleCount()
+			}
+		}
+		return downloads, networkChecks
+	}
+
+	for _, sequence := range []uint32{2, 3, 4} {
+		_, err = bsb.GetLedgerBatch(ctx, sequence)
+		require.NoError(t, err)
+	}
+	require.NoError(t, bsb.PrepareRange(ctx, BoundedRange(2, 5)))
+	for sequence := uint32(2); sequence <= 5; sequence++ {
+		_, err = bsb.GetLedger(ctx, sequence)
+		require.NoError(t, err)
+	}
+	downloads, networkChecks := sampleCounts()
+	assert.Equal(t, uint64(5), downloads)
+	assert.Equal(t, uint64(2), networkChecks)
+
+	// objects are checked again once reconfigured
+	require.NoError(t, bsb.Reconfigure(config, fakeDataStore))
+	_, err = bsb.GetLedgerBatch(ctx, 2)
+	require.NoError(t, err)
+	downloads, networkChecks = sampleCounts()
+	assert.Equal(t, uint64(6), downloads)
+	assert.Equal(t, uint64(3), networkChecks)
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBDirectReadsShareDecodePath(t *testing.T) {
+	ctx := context.Background()
+	schema := datastore.DataStoreSchema{LedgersPerFile: 4, FilesPerPartition: partitionSize}
+	fakeDataStore := ledgerbackendtest.NewFakeDataStore(schema)
+	for start := uint32(0); start <= 12; start += 4 {
+		contents, err := io.ReadAll(createLCMBatchReader(start, start+3, 4))
+		require.NoError(t, err)
+		metaData := datastore.MetaData{NetworkPassPhrase: "Test SDF Network ; September 2015"}
+		if start == 12 {
+			metaData.NetworkPassPhrase = "Public Global Stellar Network ; September 2015"
+		}
+		require.NoError(t, fakeDataStore.PutFile(ctx, schema.GetObjectKeyFromSequenceNumber(start),
+			bytes.NewReader(contents), metaData.ToMap()))
+	}
+
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.dataStore = fakeDataStore
+	bsb.config.ExpectedNetworkPassphrase = "Test SDF Network ; September 2015"
+	transformed := map[uint32]int{}
+	bsb.config.TransformFunc = func(lcm *xdr.LedgerCloseMeta) error {
+		transformed[lcm.LedgerSequence()]++
+		return nil
+	}
+	registry := prometheus.NewRegistry()
+	WithMetrics(&bsb, registry, "test")
+
+	_, err := bsb.GetLedgersBySequences(ctx, []uint32{1, 5, 6})
+	require.NoError(t, err)
+	assert.Equal(t, map[uint32]int{0: 1, 1: 1, 2: 1, 3: 1, 4: 1, 5: 1, 6: 1, 7: 1}, transformed)
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	downloads := uint64(0)
+	for _, family := range families {
+		if family.GetName() == "test_ingest_buffered_storage_backend_download_duration_seconds" {
+			downloads = family.GetMetric()[0].GetSummary().GetSampleCount()
+		}
+	}
+	assert.Equal(t, uint64(2), downloads)
+
+	// objects read while a range is prepared are released from the buffer's
+	// in-flight bytes once decoded
+	require.NoError(t, bsb.PrepareRange(ctx, BoundedRange(2, 11)))
+	_, err = bsb.GetLedger(ctx, 2)
+	require.NoError(t, err)
+	bsb.ledgerBuffer.bytesLock.Lock()
+	inFlightBytes := bsb.ledgerBuffer.inFlightBytes
+	bsb.ledgerBuffer.bytesLock.Unlock()
+	_, err = bsb.GetLedgerBatch(ctx, 9)
+	require.NoError(t, err)
+	bsb.ledgerBuffer.bytesLock.Lock()
+	assert.Equal(t, inFlightBytes, bsb.ledgerBuffer.inFlightBytes)
+	bsb.ledgerBuffer.bytesLock.Unlock()
+
+	err = bsb.ScanPartition(ctx, 0, func(xdr.LedgerCloseMeta) error { return nil })
+	assert.ErrorIs(t, err, ErrNetworkMismatch)
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBForEachLedger_TarDataStore(t *testing.T) {
+	ctx := context.Background()
+	schema := datastore.DataStoreSchema{LedgersPerFile: 4, FilesPerPartition: partitionSize}
+
+	// pack an export of ledgers [4,11] into a tar archive
+	var buf bytes.Buffer
+	writer := tar.NewWriter(&buf)
+	for i := uint32(4); i <= 11; i += 4 {
+		contents, err := io.ReadAll(createLCMBatchReader(i, i+3, 4))
+		require.NoError(t, err)
+		name := "export/" + schema.GetObjectKeyFromSequenceNumber(i)
+		require.NoError(t, writer.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(contents))}))
+		_, err = writer.Write(contents)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	archivePath := filepath.Join(t.TempDir(), "export.tar")
+	require.NoError(t, os.WriteFile(archivePath, buf.Bytes(), 0o600))
+
+	dataStore, err := datastore.NewTarDataStore(ctx, archivePath, "export", schema)
+	require.NoError(t, err)
+	bsb, err := NewBufferedStorageBackend(createBufferedStorageBackendConfigForTesting(), dataStore)
+	require.NoError(t, err)
+
+	var sequences []uint32
+	err = bsb.ForEachLedger(ctx, BoundedRange(5, 10), func(lcm xdr.LedgerCloseMeta) error {
+		sequences = append(sequences, lcm.LedgerSequence())
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{5, 6, 7, 8, 9, 10}, sequences)
+	assert.NoError(t, bsb.Close())
+	assert.NoError(t, dataStore.Close())
+}
+
+func TestBSBRangeStats(t *testing.T) {
+	ctx := context.Background()
+	schema := datastore.DataStoreSchema{LedgersPerFile: 2, FilesPerPartition: 4}
+	fakeDataStore := ledgerbackendtest.NewFakeDataStore(schema)
+	// the object starting at ledger i is i bytes long
+	for i := uint32(2); i <= 15; i += 2 {
+		fakeDataStore.SetFile(schema.GetObjectKeyFromSequenceNumber(i), make([]byte, i))
+	}
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.dataStore = fakeDataStore
+
+	// [7,12] spans the partitions [0,7] and [8,15]
+	objectCount, totalBytes, err := bsb.RangeStats(ctx, BoundedRange(7, 12))
+	require.NoError(t, err)
+	assert.Equal(t, 4, objectCount)
+	assert.Equal(t, int64(6+8+10+12), totalBytes)
+
+	objectCount, totalBytes, err = bsb.RangeStats(ctx, SingleLedgerRange(9))
+	require.NoError(t, err)
+	assert.Equal(t, 1, objectCount)
+	assert.Equal(t, int64(8), totalBytes)
+	// no object was downloaded
+	assert.Zero(t, fakeDataStore.Calls(schema.GetObjectKeyFromSequenceNumber(8)))
+
+	_, _, err = bsb.RangeStats(ctx, BoundedRange(12, 17))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.ErrorContains(t, err, schema.GetObjectKeyFromSequenceNumber(16))
+
+	_, _, err = bsb.RangeStats(ctx, UnboundedRange(2))
+	assert.EqualError(t, err, "RangeStats requires a bounded range, got [2,latest)")
+	assert.NoError(t, bsb.Close())
+}
+
+// createChainedFakeDataStore stores the ledgers in [start, end] with each
+// header linked to the hash of its predecessor, except for brokenLedger whose
+// previous ledger hash points nowhere.
+func createChainedFakeDataStore(t *testing.T, start, end, count, brokenLedger uint32) *ledgerbackendtest.FakeDataStore {
+	schema := datastore.DataStoreSchema{
+		LedgersPerFile:    count,
+		FilesPerPartition: partitionSize,
+	}
+	fakeDataStore := ledgerbackendtest.NewFakeDataStore(schema)
+	for i := start; i <= end; i = i + count {
+		batch := createTestLedgerCloseMetaBatch(i, i+count-1, count)
+		for _, lcm := range batch.LedgerCloseMetas {
+			sequence := uint32(lcm.V0.LedgerHeader.Header.LedgerSeq)
+			lcm.V0.LedgerHeader.Hash = xdr.Hash{byte(sequence)}
+			lcm.V0.LedgerHeader.Header.PreviousLedgerHash = xdr.Hash{byte(sequence - 1)}
+			if sequence == brokenLedger {
+				lcm.V0.LedgerHeader.Header.PreviousLedgerHash = xdr.Hash{0xff}
+			}
+		}
+		var buf bytes.Buffer
+		_, err := compressxdr.NewXDREncoder(compressxdr.DefaultCompressor, batch).WriteTo(&buf)
+		require.NoError(t, err)
+		fakeDataStore.SetFile(schema.GetObjectKeyFromSequenceNumber(i), buf.Bytes())
+	}
+	return fakeDataStore
+}
+
+func TestBSBVerifyChain(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.dataStore = createChainedFakeDataStore(t, 2, 9, 2, 0)
+
+	assert.NoError(t, bsb.VerifyChain(ctx, BoundedRange(2, 9)))
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBVerifyChain_AfterGetLedger(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.dataStore = createChainedFakeDataStore(t, 2, 11, 2, 0)
+
+	require.NoError(t, bsb.PrepareRange(ctx, BoundedRange(2, 11)))
+	for sequence := uint32(2); sequence <= 6; sequence++ {
+		_, err := bsb.GetLedger(ctx, sequence)
+		require.NoError(t, err)
+	}
+	// verifying a range behind the ledgers already read replaces the prepared range
+	assert.NoError(t, bsb.VerifyChain(ctx, BoundedRange(3, 9)))
+	assert.NoError(t, bsb.VerifyChain(ctx, BoundedRange(2, 11)))
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBVerifyChain_BrokenLink(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.dataStore = createChainedFakeDataStore(t, 2, 9, 2, 6)
+
+	err := bsb.VerifyChain(ctx, BoundedRange(2, 9))
+	assert.EqualError(t, err, "ledger 6 previous ledger hash "+
+		"ff00000000000000000000000000000000000000000000000000000000000000 does not match hash "+
+		"0500000000000000000000000000000000000000000000000000000000000000 of ledger 5")
+
+	// the link to the ledger preceding the range is not checked
+	assert.NoError(t, bsb.VerifyChain(ctx, BoundedRange(6, 9)))
+
+	assert.EqualError(t, bsb.VerifyChain(ctx, UnboundedRange(2)), "VerifyChain requires a bounded range, got [2,latest)")
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBMaxRangeSize(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.config.MaxRangeSize = 8
+	fakeDataStore := createChainedFakeDataStore(t, 2, 11, 2, 0)
+	bsb.dataStore = fakeDataStore
+
+	assert.NoError(t, bsb.VerifyChain(ctx, BoundedRange(2, 8)))
+	assert.NoError(t, bsb.VerifyChain(ctx, BoundedRange(2, 9)))
+	calls := fakeDataStore.TotalCalls()
+	assert.EqualError(t, bsb.VerifyChain(ctx, BoundedRange(2, 10)),
+		"VerifyChain range [2,10] exceeds the maximum range size of 8 ledgers")
+	// the range is rejected before anything is downloaded
+	assert.Equal(t, calls, fakeDataStore.TotalCalls())
+
+	ledgers, err := bsb.GetLedgerWindow(ctx, 6, 4, 3)
+	assert.NoError(t, err)
+	assert.Len(t, ledgers, 8)
+	_, err = bsb.GetLedgerWindow(ctx, 6, 4, 4)
+	assert.EqualError(t, err, "GetLedgerWindow range [2,10] exceeds the maximum range size of 8 ledgers")
+
+	// the limit can be raised or lifted for a single call
+	assert.NoError(t, bsb.VerifyChain(ctx, BoundedRange(2, 10), WithMaxRangeSize(9)))
+	assert.EqualError(t, bsb.VerifyChain(ctx, BoundedRange(2, 11), WithMaxRangeSize(9)),
+		"VerifyChain range [2,11] exceeds the maximum range size of 9 ledgers")
+	assert.NoError(t, bsb.VerifyChain(ctx, BoundedRange(2, 11), WithMaxRangeSize(0)))
+	ledgers, err = bsb.GetLedgerWindow(ctx, 6, 4, 4, WithMaxRangeSize(0))
+	assert.NoError(t, err)
+	assert.Len(t, ledgers, 9)
+	// or lowered
+	_, err = bsb.GetLedgerWindow(ctx, 6, 1, 1, WithMaxRangeSize(2))
+	assert.EqualError(t, err, "GetLedgerWindow range [5,7] exceeds the maximum range size of 2 ledgers")
+
+	// streaming is not limited
+	var count int
+	assert.NoError(t, bsb.ForEachLedger(ctx, BoundedRange(2, 11), func(xdr.LedgerCloseMeta) error {
+		count++
+		return nil
+	}))
+	assert.Equal(t, 10, count)
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBForEachLedger_Deadline(t *testing.T) {
+	bsb := createBufferedStorageBackendForTesting()
+	fakeDataStore := createFakeDataStore(t, 2, 9, 2)
+	fakeDataStore.SetLatency(fakeDataStore.GetSchema().GetObjectKeyFromSequenceNumber(6), time.Minute)
+	bsb.dataStore = fakeDataStore
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var sequences []uint32
+	err := bsb.ForEachLedger(ctx, BoundedRange(2, 9), func(lcm xdr.LedgerCloseMeta) error {
+		sequences = append(sequences, lcm.LedgerSequence())
+		return nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "deadline exceeded mid-range [2,9] after fetching 4 ledgers")
+	assert.Equal(t, []uint32{2, 3, 4, 5}, sequences)
+
+	// no ledger is read once the deadline is exceeded
+	sequences = nil
+	err = bsb.ForEachLedger(ctx, BoundedRange(2, 3), func(lcm xdr.LedgerCloseMeta) error {
+		sequences = append(sequences, lcm.LedgerSequence())
+		return nil
+	})
+	assert.EqualError(t, err, "deadline exceeded mid-range [2,3] after fetching 0 ledgers: context deadline exceeded")
+	assert.Empty(t, sequences)
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBGetLedgerWindow_SpansFileBoundary(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	fakeDataStore := createFakeDataStore(t, 2, 9, 2)
+	bsb.dataStore = fakeDataStore
+
+	ledgers, err := bsb.GetLedgerWindow(ctx, 5, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, createLCMForTesting(4, 6), ledgers)
+	// [4,5] and [6,7] are each downloaded once
+	assert.Equal(t, 2, fakeDataStore.TotalCalls())
+
+	// a second window is served after the first one was consumed
+	ledgers, err = bsb.GetLedgerWindow(ctx, 3, 0, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, createLCMForTesting(3, 4), ledgers)
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBGetLedgerWindow_Clamped(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.dataStore = createFakeDataStore(t, 2, 7, 2)
+
+	ledgers, err := bsb.GetLedgerWindow(ctx, 3, 5, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, createLCMForTesting(2, 4), ledgers)
+
+	ledgers, err = bsb.GetLedgerWindow(ctx, 6, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, createLCMForTesting(6, 7), ledgers)
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBGetLedgerWindow_MissingFirstObject(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	dataStore := &existsCountingDataStore{DataStore: ledgerbackendtest.NewFakeDataStore(datastore.DataStoreSchema{
+		LedgersPerFile:    64,
+		FilesPerPartition: partitionSize,
+	})}
+	bsb.dataStore = dataStore
+
+	// the window is within the missing first object, no other object is probed
+	_, err := bsb.GetLedgerWindow(ctx, 10, 0, 5)
+	assert.Error(t, err)
+	assert.Zero(t, dataStore.existsCalls)
+
+	// a window spanning into the next object probes only that one
+	_, err = bsb.GetLedgerWindow(ctx, 60, 0, 10)
+	assert.Error(t, err)
+	assert.Equal(t, 1, dataStore.existsCalls)
+	assert.NoError(t, bsb.Close())
+}
+
+func TestBSBGetLedgerWindow_Strict(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.config.StrictLedgerWindow = true
+	bsb.dataStore = createFakeDataStore(t, 2, 7, 2)
+
+	_, err := bsb.GetLedgerWindow(ctx, 3, 5, 1)
+	assert.EqualError(t, err, "ledger window [3-5,3+1] precedes the first ledger 2")
+
+	_, err = bsb.GetLedgerWindow(ctx, 6, 0, 10)
+	assert.EqualError(t, err, "ledger 16 in window [6-0,6+10] is not available")
+
+	ledgers, err := bsb.GetLedgerWindow(ctx, 3, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, createLCMForTesting(2, 4), ledgers)
+	assert.NoError(t, bsb.Close())
+}
@@ -0,0 +1,195 @@
+package ledgerbackend
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/stellar/go/xdr"
+)
+
+// Ensure ChannelLedgerBackend implements LedgerBackend
+var _ LedgerBackend = (*ChannelLedgerBackend)(nil)
+
+// ErrLedgerTooOld is returned by ChannelLedgerBackend.GetLedger when the
+// requested ledger was evicted from the retained window.
+var ErrLedgerTooOld = errors.New("ledger is older than the retained window")
+
+// ChannelLedgerBackend is a ledger backend serving ledgers produced in the same
+// process. Ledgers are pushed in order, either by the caller through Push or
+// from a channel, and only the most recent ones are retained in memory.
+type ChannelLedgerBackend struct {
+	lock sync.Mutex
+
+	window uint32
+	// ledgers holds the retained ledgers in ascending order, the last one
+	// being the latest pushed ledger.
+	ledgers []xdr.LedgerCloseMeta
+	latest  uint32
+	// pushed is closed and replaced whenever a ledger is pushed, waking up
+	// GetLedger calls waiting for it.
+	pushed chan struct{}
+
+	prepared *Range
+	closed   bool
+	done     chan struct{}
+}
+
+// NewChannelLedgerBackend returns a ChannelLedgerBackend retaining the latest
+// window ledgers. If ledgers is not nil, every ledger received from it is
+// pushed until the channel is closed or the backend is closed.
+func NewChannelLedgerBackend(ledgers <-chan xdr.LedgerCloseMeta, window uint32) (*ChannelLedgerBackend, error) {
+	if window == 0 {
+		return nil, errors.New("window must be > 0")
+	}
+
+	backend := &ChannelLedgerBackend{
+		window: window,
+		pushed: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if ledgers != nil {
+		go backend.consume(ledgers)
+	}
+	return backend, nil
+}
+
+func (c *ChannelLedgerBackend) consume(ledgers <-chan xdr.LedgerCloseMeta) {
+	for {
+		select {
+		case <-c.done:
+			return
+		case ledgerCloseMeta, ok := <-ledgers:
+			if !ok {
+				return
+			}
+			// Out of order ledgers cannot be served, drop them
+			_ = c.Push(ledgerCloseMeta)
+		}
+	}
+}
+
+// Push appends a ledger to the backend. Every ledger after the first one must
+// immediately follow the previously pushed ledger.
+func (c *ChannelLedgerBackend) Push(ledgerCloseMeta xdr.LedgerCloseMeta) error {
+	header, err := ledgerHeader(ledgerCloseMeta)
+	if err != nil {
+		return err
+	}
+	sequence := uint32(header.Header.LedgerSeq)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return errors.New("ChannelLedgerBackend is closed; cannot Push")
+	}
+	if c.latest != 0 && sequence != c.latest+1 {
+		return errors.Errorf("pushed ledger %d does not follow latest ledger %d", sequence, c.latest)
+	}
+
+	c.ledgers = append(c.ledgers, ledgerCloseMeta)
+	if uint32(len(c.ledgers)) > c.window {
+		c.ledgers[0] = xdr.LedgerCloseMeta{}
+		c.ledgers = c.ledgers[1:]
+	}
+	c.latest = sequence
+	close(c.pushed)
+	c.pushed = make(chan struct{})
+	return nil
+}
+
+// GetLatestLedgerSequence returns the sequence of the latest pushed ledger.
+func (c *ChannelLedgerBackend) GetLatestLedgerSequence(ctx context.Context) (uint32, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return 0, errors.New("ChannelLedgerBackend is closed; cannot GetLatestLedgerSequence")
+	}
+	if c.latest == 0 {
+		return 0, errors.New("no ledger has been pushed yet")
+	}
+	return c.latest, nil
+}
+
+// GetLedger returns the requested ledger, blocking until it is pushed. It
+// returns ErrLedgerTooOld if the ledger was evicted from the retained window.
+func (c *ChannelLedgerBackend) GetLedger(ctx context.Context, sequence uint32) (xdr.LedgerCloseMeta, error) {
+	for {
+		c.lock.Lock()
+		ledgerCloseMeta, pushed, err := c.getLedger(sequence)
+		c.lock.Unlock()
+		if err != nil || pushed == nil {
+			return ledgerCloseMeta, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return xdr.LedgerCloseMeta{}, ctx.Err()
+		case <-c.done:
+		case <-pushed:
+		}
+	}
+}
+
+// getLedger returns the requested ledger if it is retained. Otherwise, if the
+// ledger was not pushed yet, it returns a channel closed on the next push.
+func (c *ChannelLedgerBackend) getLedger(sequence uint32) (xdr.LedgerCloseMeta, <-chan struct{}, error) {
+	if c.closed {
+		return xdr.LedgerCloseMeta{}, nil, errors.New("ChannelLedgerBackend is closed; cannot GetLedger")
+	}
+	if c.prepared == nil {
+		return xdr.LedgerCloseMeta{}, nil, errors.New("session is not prepared, call PrepareRange first")
+	}
+	if sequence < c.prepared.from || (c.prepared.bounded && sequence > c.prepared.to) {
+		return xdr.LedgerCloseMeta{}, nil, errors.Errorf("requested sequence %d is outside of prepared range %s", sequence, *c.prepared)
+	}
+	if c.latest == 0 || sequence > c.latest {
+		return xdr.LedgerCloseMeta{}, c.pushed, nil
+	}
+
+	oldest := c.latest - uint32(len(c.ledgers)) + 1
+	if sequence < oldest {
+		return xdr.LedgerCloseMeta{}, nil, errors.Wrapf(ErrLedgerTooOld, "ledger %d, oldest retained ledger is %d", sequence, oldest)
+	}
+	return c.ledgers[sequence-oldest], nil, nil
+}
+
+// PrepareRange sets the range served by GetLedger. It does not wait for
+// ledgers to be pushed.
+func (c *ChannelLedgerBackend) PrepareRange(ctx context.Context, ledgerRange Range) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return errors.New("ChannelLedgerBackend is closed; cannot PrepareRange")
+	}
+	c.prepared = &ledgerRange
+	return nil
+}
+
+// IsPrepared returns true if the given range lies within the prepared range.
+func (c *ChannelLedgerBackend) IsPrepared(ctx context.Context, ledgerRange Range) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return false, errors.New("ChannelLedgerBackend is closed; cannot IsPrepared")
+	}
+	return c.prepared != nil && c.prepared.Contains(ledgerRange), nil
+}
+
+// Close releases the retained ledgers and stops consuming the channel.
+func (c *ChannelLedgerBackend) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.closed {
+		c.closed = true
+		c.ledgers = nil
+		close(c.done)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package ledgerbackend
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/xdr"
+)
+
+func TestChannelLedgerBackend(t *testing.T) {
+	ctx := context.Background()
+	ledgers := make(chan xdr.LedgerCloseMeta)
+	backend, err := NewChannelLedgerBackend(ledgers, 3)
+	require.NoError(t, err)
+
+	require.NoError(t, backend.PrepareRange(ctx, UnboundedRange(2)))
+	prepared, err := backend.IsPrepared(ctx, BoundedRange(5, 10))
+	require.NoError(t, err)
+	assert.True(t, prepared)
+
+	_, err = backend.GetLatestLedgerSequence(ctx)
+	assert.EqualError(t, err, "no ledger has been pushed yet")
+
+	// GetLedger blocks until the ledger is pushed
+	received := make(chan xdr.LedgerCloseMeta)
+	go func() {
+		lcm, getErr := backend.GetLedger(ctx, 3)
+		assert.NoError(t, getErr)
+		received <- lcm
+	}()
+	ledgers <- createLedgerCloseMeta(2)
+	ledgers <- createLedgerCloseMeta(3)
+	assert.Equal(t, createLedgerCloseMeta(3), <-received)
+	for _, lcm := range createLCMForTesting(4, 6) {
+		ledgers <- lcm
+	}
+
+	require.Eventually(t, func() bool {
+		latest, latestErr := backend.GetLatestLedgerSequence(ctx)
+		return latestErr == nil && latest == 6
+	}, time.Second, time.Millisecond)
+	for sequence := uint32(4); sequence <= 6; sequence++ {
+		lcm, getErr := backend.GetLedger(ctx, sequence)
+		require.NoError(t, getErr)
+		assert.Equal(t, createLedgerCloseMeta(sequence), lcm)
+	}
+
+	// only the latest 3 ledgers are retained
+	_, err = backend.GetLedger(ctx, 3)
+	assert.ErrorIs(t, err, ErrLedgerTooOld)
+	assert.EqualError(t, err, "ledger 3, oldest retained ledger is 4: ledger is older than the retained window")
+
+	require.NoError(t, backend.Push(createLedgerCloseMeta(7)))
+	assert.EqualError(t, backend.Push(createLedgerCloseMeta(9)), "pushed ledger 9 does not follow latest ledger 7")
+	_, err = backend.GetLedger(ctx, 4)
+	assert.ErrorIs(t, err, ErrLedgerTooOld)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = backend.GetLedger(timeoutCtx, 8)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, backend.Close())
+	_, err = backend.GetLedger(ctx, 7)
+	assert.EqualError(t, err, "ChannelLedgerBackend is closed; cannot GetLedger")
+	assert.EqualError(t, backend.Push(createLedgerCloseMeta(8)), "ChannelLedgerBackend is closed; cannot Push")
+}
+
+func TestChannelLedgerBackendPreparedRange(t *testing.T) {
+	ctx := context.Background()
+	backend, err := NewChannelLedgerBackend(nil, 10)
+	require.NoError(t, err)
+
+	_, err = backend.GetLedger(ctx, 2)
+	assert.EqualError(t, err, "session is not prepared, call PrepareRange first")
+
+	require.NoError(t, backend.PrepareRange(ctx, BoundedRange(3, 4)))
+	for _, lcm := range createLCMForTesting(2, 5) {
+		require.NoError(t, backend.Push(lcm))
+	}
+	_, err = backend.GetLedger(ctx, 5)
+	assert.EqualError(t, err, "requested sequence 5 is outside of prepared range [3,4]")
+	lcm, err := backend.GetLedger(ctx, 4)
+	require.NoError(t, err)
+	assert.Equal(t, createLedgerCloseMeta(4), lcm)
+
+	prepared, err := backend.IsPrepared(ctx, UnboundedRange(3))
+	require.NoError(t, err)
+	assert.False(t, prepared)
+	require.NoError(t, backend.Close())
+
+	_, err = NewChannelLedgerBackend(nil, 0)
+	assert.EqualError(t, err, "window must be > 0")
+}
@@ -0,0 +1,163 @@
+package ledgerbackend
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LatestSequenceWatcher notifies callbacks when the latest ledger sequence of a
+// LedgerBackend advances. All watchers share a single poll loop, which runs at
+// the smallest interval requested by the active watchers and only while there
+// is at least one of them.
+type LatestSequenceWatcher struct {
+	backend LedgerBackend
+
+	lock     sync.Mutex
+	watches  map[*latestWatch]struct{}
+	stopLoop context.CancelFunc
+	wake     chan struct{}
+}
+
+type latestWatch struct {
+	interval time.Duration
+	cb       func(sequence uint32)
+
+	// lock serializes cb with stop so that cb is never invoked once stop returns
+	lock    sync.Mutex
+	stopped bool
+	last    uint32
+}
+
+// NewLatestSequenceWatcher returns a LatestSequenceWatcher polling
+// backend.GetLatestLedgerSequence.
+func NewLatestSequenceWatcher(backend LedgerBackend) *LatestSequenceWatcher {
+	return &LatestSequenceWatcher{
+		backend: backend,
+		watches: map[*latestWatch]struct{}{},
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// WatchLatest invokes cb whenever the latest ledger sequence differs from the
+// last sequence passed to cb, checking at least every interval. The first
+// sequence observed is always passed to cb. Errors returned by the backend
+// are ignored and the sequence is polled again at the next tick.
+// The watch ends when ctx is done or when the returned stop function is
+// called. cb is never invoked after stop returns, so cb must not call stop.
+// interval must be > 0.
+func (w *LatestSequenceWatcher) WatchLatest(ctx context.Context, interval time.Duration, cb func(sequence uint32)) (stop func(), err error) {
+	if interval <= 0 {
+		return nil, errors.New("interval must be > 0")
+	}
+	watch := &latestWatch{interval: interval, cb: cb}
+
+	w.lock.Lock()
+	w.watches[watch] = struct{}{}
+	if w.stopLoop == nil {
+		var loopCtx context.Context
+		loopCtx, w.stopLoop = context.WithCancel(context.Background())
+		go w.run(loopCtx)
+	}
+	w.lock.Unlock()
+	w.wakeLoop()
+
+	done := make(chan struct{})
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			close(done)
+			watch.lock.Lock()
+			watch.stopped = true
+			watch.lock.Unlock()
+
+			w.lock.Lock()
+			delete(w.watches, watch)
+			if len(w.watches) == 0 {
+				w.stopLoop()
+				w.stopLoop = nil
+			}
+			w.lock.Unlock()
+			w.wakeLoop()
+		})
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			stop()
+		case <-done:
+		}
+	}()
+	return stop, nil
+}
+
+// wakeLoop makes the poll loop recompute its interval.
+func (w *LatestSequenceWatcher) wakeLoop() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// snapshot returns the active watches and the smallest of their intervals.
+func (w *LatestSequenceWatcher) snapshot() ([]*latestWatch, time.Duration) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	watches := make([]*latestWatch, 0, len(w.watches))
+	var interval time.Duration
+	for watch := range w.watches {
+		watches = append(watches, watch)
+		if interval == 0 || watch.interval < interval {
+			interval = watch.interval
+		}
+	}
+	return watches, interval
+}
+
+// run polls the backend until ctx is done or there are no watches left, which
+// can happen before the loop starts if every watch is stopped right away.
+func (w *LatestSequenceWatcher) run(ctx context.Context) {
+	_, interval := w.snapshot()
+	if interval == 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.wake:
+			if _, interval = w.snapshot(); interval == 0 {
+				return
+			}
+			ticker.Reset(interval)
+			continue
+		case <-ticker.C:
+		}
+
+		sequence, err := w.backend.GetLatestLedgerSequence(ctx)
+		if err != nil {
+			continue
+		}
+		watches, _ := w.snapshot()
+		for _, watch := range watches {
+			watch.notify(sequence)
+		}
+	}
+}
+
+func (watch *latestWatch) notify(sequence uint32) {
+	watch.lock.Lock()
+	defer watch.lock.Unlock()
+
+	if watch.stopped || watch.last == sequence {
+		return
+	}
+	watch.last = sequence
+	watch.cb(sequence)
+}
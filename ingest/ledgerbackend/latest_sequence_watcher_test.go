@@ -0,0 +1,117 @@
+package ledgerbackend
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type latestSequenceBackend struct {
+	LedgerBackend
+	latest atomic.Uint32
+	polls  atomic.Int32
+}
+
+func (b *latestSequenceBackend) GetLatestLedgerSequence(ctx context.Context) (uint32, error) {
+	b.polls.Add(1)
+	return b.latest.Load(), nil
+}
+
+type sequenceRecorder struct {
+	lock      sync.Mutex
+	sequences []uint32
+}
+
+func (r *sequenceRecorder) record(sequence uint32) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.sequences = append(r.sequences, sequence)
+}
+
+func (r *sequenceRecorder) get() []uint32 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]uint32(nil), r.sequences...)
+}
+
+func TestWatchLatest(t *testing.T) {
+	backend := &latestSequenceBackend{}
+	backend.latest.Store(10)
+	watcher := NewLatestSequenceWatcher(backend)
+
+	var first, second sequenceRecorder
+	stopFirst, err := watcher.WatchLatest(context.Background(), time.Millisecond, first.record)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err = watcher.WatchLatest(ctx, time.Hour, second.record)
+	require.NoError(t, err)
+
+	waitForSequences := func(recorder *sequenceRecorder, expected ...uint32) {
+		require.Eventually(t, func() bool {
+			return assert.ObjectsAreEqual(expected, recorder.get())
+		}, time.Second, time.Millisecond)
+	}
+
+	// both watchers are served by the loop polling every millisecond
+	waitForSequences(&first, 10)
+	waitForSequences(&second, 10)
+	polls := backend.polls.Load()
+	require.Eventually(t, func() bool { return backend.polls.Load() > polls+5 }, time.Second, time.Millisecond)
+	assert.Equal(t, []uint32{10}, first.get())
+
+	backend.latest.Store(11)
+	waitForSequences(&first, 10, 11)
+	waitForSequences(&second, 10, 11)
+
+	cancel()
+	stopFirst()
+	stopFirst()
+	// the watch is stopped asynchronously once its context is cancelled
+	require.Eventually(t, func() bool {
+		watcher.lock.Lock()
+		defer watcher.lock.Unlock()
+		return len(watcher.watches) == 0
+	}, time.Second, time.Millisecond)
+	backend.latest.Store(12)
+	polls = backend.polls.Load()
+	time.Sleep(20 * time.Millisecond)
+	assert.LessOrEqual(t, backend.polls.Load(), polls+1)
+	assert.Equal(t, []uint32{10, 11}, first.get())
+	assert.Equal(t, []uint32{10, 11}, second.get())
+
+	// the poll loop is restarted by a new watcher
+	var third sequenceRecorder
+	stopThird, err := watcher.WatchLatest(context.Background(), time.Millisecond, third.record)
+	require.NoError(t, err)
+	waitForSequences(&third, 12)
+	stopThird()
+}
+
+func TestWatchLatestStopImmediately(t *testing.T) {
+	backend := &latestSequenceBackend{}
+	watcher := NewLatestSequenceWatcher(backend)
+
+	_, err := watcher.WatchLatest(context.Background(), 0, func(uint32) {})
+	assert.EqualError(t, err, "interval must be > 0")
+
+	goroutines := runtime.NumGoroutine()
+	// stopping the only watch before the poll loop reads its interval must
+	// not start a ticker with a zero interval
+	for i := 0; i < 1000; i++ {
+		stop, err := watcher.WatchLatest(context.Background(), time.Millisecond, func(uint32) {})
+		require.NoError(t, err)
+		stop()
+	}
+	// neither the poll loops nor the goroutines waiting for the never
+	// cancelled context outlive the watches
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > goroutines && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), goroutines)
+}
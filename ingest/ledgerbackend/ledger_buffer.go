@@ -21,10 +21,25 @@ type ledgerBatchObject struct {
 	startLedger int // Ledger sequence used as the priority for the priorityqueue.
 }
 
-type ledgerBuffer struct {
-	// Passed through from BufferedStorageBackend to control lifetime of ledgerBuffer instance
+// objectReader downloads and decodes ledger objects. It is shared by the
+// ledgerBuffer workers and by the BufferedStorageBackend methods reading
+// objects directly, so that every object goes through the same checks,
+// metrics and TransformFunc.
+type objectReader struct {
 	config    BufferedStorageBackendConfig
 	dataStore datastore.DataStore
+	metrics   bufferedStorageMetrics
+	// checkedKeys, if set, caches the objects whose network passphrase matched
+	checkedKeys *sync.Map
+}
+
+func (bsb *BufferedStorageBackend) objectReader() objectReader {
+	return objectReader{config: bsb.config, dataStore: bsb.dataStore, metrics: bsb.metrics, checkedKeys: bsb.checkedKeys}
+}
+
+type ledgerBuffer struct {
+	// Passed through from BufferedStorageBackend to control lifetime of ledgerBuffer instance
+	objectReader
 
 	// context used to cancel workers within the ledgerBuffer
 	context context.Context
@@ -46,6 +61,13 @@ type ledgerBuffer struct {
 	nextTaskLedger    uint32 // The next task ledger that should be added to taskQueue
 	ledgerRange       Range
 	currentLedgerLock sync.RWMutex
+
+	// inFlightBytes is the compressed size of the objects stored in the
+	// ledgerPriorityQueue and ledgerQueue, bounded by config.MaxInFlightBytes.
+	// bytesReleased is closed and replaced whenever objects are consumed.
+	inFlightBytes uint64
+	bytesReleased chan struct{}
+	bytesLock     sync.Mutex
 }
 
 func (bsb *BufferedStorageBackend) newLedgerBuffer(ledgerRange Range) (*ledgerBuffer, error) {
@@ -57,8 +79,7 @@ func (bsb *BufferedStorageBackend) newLedgerBuffer(ledgerRange Range) (*ledgerBu
 	pq := heap.New(less, int(bsb.config.BufferSize))
 
 	ledgerBuffer := &ledgerBuffer{
-		config:              bsb.config,
-		dataStore:           bsb.dataStore,
+		objectReader:        bsb.objectReader(),
 		taskQueue:           make(chan uint32, bsb.config.BufferSize),
 		ledgerQueue:         make(chan []byte, bsb.config.BufferSize),
 		ledgerPriorityQueue: pq,
@@ -67,6 +88,7 @@ func (bsb *BufferedStorageBackend) newLedgerBuffer(ledgerRange Range) (*ledgerBu
 		ledgerRange:         ledgerRange,
 		context:             ctx,
 		cancel:              cancel,
+		bytesReleased:       make(chan struct{}),
 	}
 
 	// Start workers to read LCM files
@@ -90,8 +112,10 @@ func (bsb *BufferedStorageBackend) newLedgerBuffer(ledgerRange Range) (*ledgerBu
 }
 
 func (lb *ledgerBuffer) pushTaskQueue() {
-	// In bounded mode, don't queue past the end ledger
-	if lb.nextTaskLedger > lb.ledgerRange.to && lb.ledgerRange.bounded {
+	// In bounded mode, don't queue past the end ledger. The task sequence may not be
+	// aligned to an object boundary, so compare the start of the object containing it.
+	if lb.ledgerRange.bounded &&
+		lb.dataStore.GetSchema().GetSequenceNumberStartBoundary(lb.nextTaskLedger) > lb.ledgerRange.to {
 		return
 	}
 	lb.taskQueue <- lb.nextTaskLedger
@@ -138,6 +162,10 @@ func (lb *ledgerBuffer) worker(ctx context.Context) {
 					if errors.Is(err, context.Canceled) {
 						return
 					}
+					if errors.Is(err, ErrNetworkMismatch) {
+						lb.cancel(err)
+						return
+					}
 					if attempt == lb.config.RetryLimit {
 						err = errors.Wrapf(err, "maximum retries exceeded for downloading object containing sequence %v", sequence)
 						lb.cancel(err)
@@ -155,6 +183,9 @@ func (lb *ledgerBuffer) worker(ctx context.Context) {
 				// Thus, the number of tasks decreases by 1 and the priority queue length increases by 1.
 				// This keeps the overall total the same (<= BufferSize). As long as the the ledger buffer invariant
 				// was maintained in the previous state, it is still maintained during this state transition.
+				if !lb.reserveBytes(ctx, ledgerObject, sequence) {
+					return
+				}
 				lb.storeObject(ledgerObject, sequence)
 				break
 			}
@@ -162,10 +193,14 @@ func (lb *ledgerBuffer) worker(ctx context.Context) {
 	}
 }
 
-func (lb *ledgerBuffer) downloadLedgerObject(ctx context.Context, sequence uint32) ([]byte, error) {
-	objectKey := lb.dataStore.GetSchema().GetObjectKeyFromSequenceNumber(sequence)
+func (r objectReader) downloadLedgerObject(ctx context.Context, sequence uint32) ([]byte, error) {
+	objectKey := r.dataStore.GetSchema().GetObjectKeyFromSequenceNumber(sequence)
+	if err := r.checkNetwork(ctx, objectKey); err != nil {
+		return nil, err
+	}
 
-	reader, err := lb.dataStore.GetFile(ctx, objectKey)
+	defer observeDuration(r.metrics.downloadDuration, time.Now())
+	reader, err := r.dataStore.GetFile(ctx, objectKey)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to retrieve file: %s", objectKey)
 	}
@@ -180,6 +215,98 @@ func (lb *ledgerBuffer) downloadLedgerObject(ctx context.Context, sequence uint3
 	return objectBytes, nil
 }
 
+// checkNetwork compares config.ExpectedNetworkPassphrase, if set, with the
+// network passphrase in the metadata of the given object, unless the object
+// was already checked.
+func (r objectReader) checkNetwork(ctx context.Context, objectKey string) error {
+	expected := r.config.ExpectedNetworkPassphrase
+	if expected == "" {
+		return nil
+	}
+	if r.checkedKeys != nil {
+		if _, ok := r.checkedKeys.Load(objectKey); ok {
+			return nil
+		}
+	}
+	defer observeDuration(r.metrics.networkCheckDuration, time.Now())
+
+	rawMetaData, err := r.dataStore.GetFileMetadata(ctx, objectKey)
+	if err != nil {
+		return errors.Wrapf(err, "unable to retrieve metadata of file: %s", objectKey)
+	}
+	metaData, err := datastore.NewMetaDataFromMap(rawMetaData)
+	if err != nil {
+		return errors.Wrapf(err, "unable to parse metadata of file: %s", objectKey)
+	}
+	if actual := metaData.NetworkPassPhrase; actual != expected {
+		return errors.Wrapf(ErrNetworkMismatch, "file %s was exported from network %q, expected %q",
+			objectKey, actual, expected)
+	}
+	if r.checkedKeys != nil {
+		r.checkedKeys.Store(objectKey, struct{}{})
+	}
+	return nil
+}
+
+// reserveBytes accounts for ledgerObject in inFlightBytes, waiting until there
+// is room for it under config.MaxInFlightBytes. The object is admitted
+// regardless of the cap if the buffer is empty, or if it is the next object to
+// be consumed and the consumer has nothing else to read, as waiting would then
+// never end. It returns false if ctx is done before the object is admitted.
+func (lb *ledgerBuffer) reserveBytes(ctx context.Context, ledgerObject []byte, sequence uint32) bool {
+	size := uint64(len(ledgerObject))
+	for {
+		// released is captured before checking whether the consumer is blocked
+		// on this object, so that a release in between wakes the wait below
+		lb.bytesLock.Lock()
+		released := lb.bytesReleased
+		lb.bytesLock.Unlock()
+
+		lb.currentLedgerLock.RLock()
+		blocking := lb.currentLedger == sequence && len(lb.ledgerQueue) == 0
+		lb.currentLedgerLock.RUnlock()
+
+		lb.bytesLock.Lock()
+		if lb.config.MaxInFlightBytes == 0 || blocking || lb.inFlightBytes == 0 ||
+			lb.inFlightBytes+size <= lb.config.MaxInFlightBytes {
+			lb.inFlightBytes += size
+			lb.bytesLock.Unlock()
+			return true
+		}
+		lb.bytesLock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-released:
+		}
+	}
+}
+
+// holdBytes accounts for an object read outside of the buffer in
+// inFlightBytes, without waiting for room under config.MaxInFlightBytes since
+// the reader may be the consumer the buffer is waiting for. Workers leave room
+// for the object until the returned function is called.
+func (lb *ledgerBuffer) holdBytes(ledgerObject []byte) (release func()) {
+	lb.bytesLock.Lock()
+	defer lb.bytesLock.Unlock()
+
+	lb.inFlightBytes += uint64(len(ledgerObject))
+	return func() {
+		lb.releaseBytes(ledgerObject)
+	}
+}
+
+// releaseBytes removes a consumed object from inFlightBytes.
+func (lb *ledgerBuffer) releaseBytes(ledgerObject []byte) {
+	lb.bytesLock.Lock()
+	defer lb.bytesLock.Unlock()
+
+	lb.inFlightBytes -= uint64(len(ledgerObject))
+	close(lb.bytesReleased)
+	lb.bytesReleased = make(chan struct{})
+}
+
 func (lb *ledgerBuffer) storeObject(ledgerObject []byte, sequence uint32) {
 	lb.priorityQueueLock.Lock()
 	defer lb.priorityQueueLock.Unlock()
@@ -202,13 +329,15 @@ func (lb *ledgerBuffer) storeObject(ledgerObject []byte, sequence uint32) {
 	}
 }
 
-func (lb *ledgerBuffer) getFromLedgerQueue(ctx context.Context) (xdr.LedgerCloseMetaBatch, error) {
+// getFromLedgerQueue returns the next downloaded ledger object, to be decoded
+// with decodeObject.
+func (lb *ledgerBuffer) getFromLedgerQueue(ctx context.Context) ([]byte, error) {
 	for {
 		select {
 		case <-lb.context.Done():
-			return xdr.LedgerCloseMetaBatch{}, context.Cause(lb.context)
+			return nil, context.Cause(lb.context)
 		case <-ctx.Done():
-			return xdr.LedgerCloseMetaBatch{}, ctx.Err()
+			return nil, ctx.Err()
 		case compressedBinary := <-lb.ledgerQueue:
 			// The ledger buffer invariant is maintained here because
 			// we create an extra task when consuming one item from the ledger queue.
@@ -216,17 +345,63 @@ func (lb *ledgerBuffer) getFromLedgerQueue(ctx context.Context) (xdr.LedgerClose
 			// The overall sum below remains the same:
 			// len(taskQueue) + len(ledgerQueue) + ledgerPriorityQueue.Len() <= bsb.config.BufferSize
 			lb.pushTaskQueue()
+			lb.releaseBytes(compressedBinary)
 
-			lcmBatch := xdr.LedgerCloseMetaBatch{}
-			decoder := compressxdr.NewXDRDecoder(compressxdr.DefaultCompressor, &lcmBatch)
-			_, err := decoder.ReadFrom(bytes.NewReader(compressedBinary))
-			if err != nil {
-				return xdr.LedgerCloseMetaBatch{}, err
-			}
+			return compressedBinary, nil
+		}
+	}
+}
 
-			return lcmBatch, nil
+// decodeObject decodes a downloaded ledger object into the batch returned to
+// callers: the batch is checked if config.VerifyBatchIntegrity is set and
+// every ledger is passed to config.TransformFunc, if set.
+func (r objectReader) decodeObject(compressedBinary []byte) (xdr.LedgerCloseMetaBatch, error) {
+	lcmBatch, err := r.decodeBatch(compressedBinary)
+	if err != nil {
+		return xdr.LedgerCloseMetaBatch{}, err
+	}
+	if r.config.VerifyBatchIntegrity {
+		if err = verifyBatch(lcmBatch); err != nil {
+			return xdr.LedgerCloseMetaBatch{}, err
+		}
+	}
+	if r.config.TransformFunc != nil {
+		for i := range lcmBatch.LedgerCloseMetas {
+			if err = r.config.TransformFunc(&lcmBatch.LedgerCloseMetas[i]); err != nil {
+				return xdr.LedgerCloseMetaBatch{}, errors.Wrapf(err, "error transforming ledger %d",
+					LedgerSequence(lcmBatch.LedgerCloseMetas[i]))
+			}
 		}
 	}
+	return lcmBatch, nil
+}
+
+// decodeBatch decompresses and unmarshals a ledger object. The two steps are
+// done separately so that their durations can be reported independently.
+func (r objectReader) decodeBatch(compressedBinary []byte) (xdr.LedgerCloseMetaBatch, error) {
+	startTime := time.Now()
+	reader, err := compressxdr.DefaultCompressor.NewReader(bytes.NewReader(compressedBinary))
+	if err != nil {
+		return xdr.LedgerCloseMetaBatch{}, err
+	}
+	defer reader.Close()
+
+	binary, err := io.ReadAll(reader)
+	if err != nil {
+		return xdr.LedgerCloseMetaBatch{}, err
+	}
+	observeDuration(r.metrics.decompressDuration, startTime)
+	addBytes(r.metrics.compressedBytes, len(compressedBinary))
+	addBytes(r.metrics.decompressedBytes, len(binary))
+
+	startTime = time.Now()
+	lcmBatch := xdr.LedgerCloseMetaBatch{}
+	if err = lcmBatch.UnmarshalBinary(binary); err != nil {
+		return xdr.LedgerCloseMetaBatch{}, err
+	}
+	observeDuration(r.metrics.unmarshalDuration, startTime)
+
+	return lcmBatch, nil
 }
 
 func (lb *ledgerBuffer) getLatestLedgerSequence() (uint32, error) {
@@ -0,0 +1,125 @@
+package ledgerbackend
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/stellar/go/xdr"
+)
+
+// ledgerHeader returns the header of the given ledger. Unlike
+// xdr.LedgerCloseMeta.LedgerHeaderHistoryEntry it returns an error instead
+// of panicking on unsupported or malformed meta versions.
+func ledgerHeader(lcm xdr.LedgerCloseMeta) (xdr.LedgerHeaderHistoryEntry, error) {
+	switch lcm.V {
+	case 0:
+		if lcm.V0 != nil {
+			return lcm.V0.LedgerHeader, nil
+		}
+	case 1:
+		if lcm.V1 != nil {
+			return lcm.V1.LedgerHeader, nil
+		}
+	default:
+		return xdr.LedgerHeaderHistoryEntry{}, errors.Errorf("unsupported LedgerCloseMeta.V: %d", lcm.V)
+	}
+	return xdr.LedgerHeaderHistoryEntry{}, errors.Errorf("LedgerCloseMeta.V%d is missing", lcm.V)
+}
+
+// CloseTime returns the close time of the given ledger in UTC.
+func CloseTime(lcm xdr.LedgerCloseMeta) (time.Time, error) {
+	header, err := ledgerHeader(lcm)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(header.Header.ScpValue.CloseTime), 0).UTC(), nil
+}
+
+// LedgerSequence returns the sequence of the given ledger, or 0 if the
+// meta version is unsupported or malformed.
+func LedgerSequence(lcm xdr.LedgerCloseMeta) uint32 {
+	header, err := ledgerHeader(lcm)
+	if err != nil {
+		return 0
+	}
+	return uint32(header.Header.LedgerSeq)
+}
+
+// transactionProcessing returns the meta of the transactions applied in the
+// given ledger, in apply order.
+func transactionProcessing(lcm xdr.LedgerCloseMeta) ([]xdr.TransactionResultMeta, error) {
+	switch lcm.V {
+	case 0:
+		if lcm.V0 == nil {
+			return nil, errors.New("LedgerCloseMeta.V0 is missing")
+		}
+		return lcm.V0.TxProcessing, nil
+	case 1:
+		if lcm.V1 == nil {
+			return nil, errors.New("LedgerCloseMeta.V1 is missing")
+		}
+		return lcm.V1.TxProcessing, nil
+	default:
+		return nil, errors.Errorf("unsupported LedgerCloseMeta.V: %d", lcm.V)
+	}
+}
+
+// TransactionResults returns the results of the transactions applied in the
+// given ledger, in apply order.
+func TransactionResults(lcm xdr.LedgerCloseMeta) ([]xdr.TransactionResultPair, error) {
+	processing, err := transactionProcessing(lcm)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]xdr.TransactionResultPair, len(processing))
+	for i, meta := range processing {
+		results[i] = meta.Result
+	}
+	return results, nil
+}
+
+// ContractEvents returns the contract events emitted by the Soroban
+// transactions applied in the given ledger, in apply order. Only V3
+// transaction meta carries contract events, so ledgers closed before Soroban
+// have none.
+func ContractEvents(lcm xdr.LedgerCloseMeta) ([]xdr.ContractEvent, error) {
+	processing, err := transactionProcessing(lcm)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []xdr.ContractEvent
+	for i, meta := range processing {
+		if meta.TxApplyProcessing.V != 3 {
+			continue
+		}
+		if meta.TxApplyProcessing.V3 == nil {
+			return nil, errors.Errorf("TransactionMeta.V3 of transaction %d is missing", i)
+		}
+		if sorobanMeta := meta.TxApplyProcessing.V3.SorobanMeta; sorobanMeta != nil {
+			events = append(events, sorobanMeta.Events...)
+		}
+	}
+	return events, nil
+}
+
+// Upgrades returns the network upgrades applied in the given ledger, along
+// with the ledger entry changes they caused, in apply order.
+func Upgrades(lcm xdr.LedgerCloseMeta) ([]xdr.UpgradeEntryMeta, error) {
+	switch lcm.V {
+	case 0:
+		if lcm.V0 == nil {
+			return nil, errors.New("LedgerCloseMeta.V0 is missing")
+		}
+		return lcm.V0.UpgradesProcessing, nil
+	case 1:
+		if lcm.V1 == nil {
+			return nil, errors.New("LedgerCloseMeta.V1 is missing")
+		}
+		return lcm.V1.UpgradesProcessing, nil
+	default:
+		return nil, errors.Errorf("unsupported LedgerCloseMeta.V: %d", lcm.V)
+	}
+}
@@ -0,0 +1,182 @@
+package ledgerbackend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/go/xdr"
+)
+
+func TestCloseTime(t *testing.T) {
+	header := xdr.LedgerHeaderHistoryEntry{
+		Header: xdr.LedgerHeader{
+			LedgerSeq: 10,
+			ScpValue:  xdr.StellarValue{CloseTime: 1700000000},
+		},
+	}
+	expected := time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)
+
+	closeTime, err := CloseTime(xdr.LedgerCloseMeta{
+		V:  0,
+		V0: &xdr.LedgerCloseMetaV0{LedgerHeader: header},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, closeTime)
+	assert.Equal(t, time.UTC, closeTime.Location())
+
+	closeTime, err = CloseTime(xdr.LedgerCloseMeta{
+		V:  1,
+		V1: &xdr.LedgerCloseMetaV1{LedgerHeader: header},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, closeTime)
+
+	_, err = CloseTime(xdr.LedgerCloseMeta{V: 1})
+	assert.EqualError(t, err, "LedgerCloseMeta.V1 is missing")
+
+	_, err = CloseTime(xdr.LedgerCloseMeta{V: 2})
+	assert.EqualError(t, err, "unsupported LedgerCloseMeta.V: 2")
+}
+
+func TestLedgerSequence(t *testing.T) {
+	header := xdr.LedgerHeaderHistoryEntry{
+		Header: xdr.LedgerHeader{LedgerSeq: 10},
+	}
+
+	assert.Equal(t, uint32(10), LedgerSequence(xdr.LedgerCloseMeta{
+		V:  0,
+		V0: &xdr.LedgerCloseMetaV0{LedgerHeader: header},
+	}))
+	assert.Equal(t, uint32(10), LedgerSequence(xdr.LedgerCloseMeta{
+		V:  1,
+		V1: &xdr.LedgerCloseMetaV1{LedgerHeader: header},
+	}))
+	assert.Equal(t, uint32(0), LedgerSequence(xdr.LedgerCloseMeta{V: 1}))
+	assert.Equal(t, uint32(0), LedgerSequence(xdr.LedgerCloseMeta{V: 2}))
+}
+
+func TestTransactionResults(t *testing.T) {
+	results := []xdr.TransactionResultPair{
+		{Result: xdr.TransactionResult{FeeCharged: 100, Result: xdr.TransactionResultResult{Code: xdr.TransactionResultCodeTxSuccess}}},
+		{Result: xdr.TransactionResult{FeeCharged: 200, Result: xdr.TransactionResultResult{Code: xdr.TransactionResultCodeTxFailed}}},
+		{Result: xdr.TransactionResult{FeeCharged: 300, Result: xdr.TransactionResultResult{Code: xdr.TransactionResultCodeTxBadSeq}}},
+	}
+	processing := make([]xdr.TransactionResultMeta, len(results))
+	for i, result := range results {
+		processing[i] = xdr.TransactionResultMeta{Result: result}
+	}
+
+	actual, err := TransactionResults(xdr.LedgerCloseMeta{
+		V:  0,
+		V0: &xdr.LedgerCloseMetaV0{TxProcessing: processing},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, results, actual)
+
+	actual, err = TransactionResults(xdr.LedgerCloseMeta{
+		V:  1,
+		V1: &xdr.LedgerCloseMetaV1{TxProcessing: processing},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, results, actual)
+
+	actual, err = TransactionResults(xdr.LedgerCloseMeta{V: 1, V1: &xdr.LedgerCloseMetaV1{}})
+	assert.NoError(t, err)
+	assert.Empty(t, actual)
+
+	_, err = TransactionResults(xdr.LedgerCloseMeta{V: 0})
+	assert.EqualError(t, err, "LedgerCloseMeta.V0 is missing")
+
+	_, err = TransactionResults(xdr.LedgerCloseMeta{V: 2})
+	assert.EqualError(t, err, "unsupported LedgerCloseMeta.V: 2")
+}
+
+func TestContractEvents(t *testing.T) {
+	contractID := xdr.Hash{1}
+	event := func(value uint32) xdr.ContractEvent {
+		return xdr.ContractEvent{
+			ContractId: &contractID,
+			Type:       xdr.ContractEventTypeContract,
+			Body: xdr.ContractEventBody{
+				V: 0,
+				V0: &xdr.ContractEventV0{
+					Data: xdr.ScVal{Type: xdr.ScValTypeScvU32, U32: (*xdr.Uint32)(&value)},
+				},
+			},
+		}
+	}
+	sorobanMeta := func(events ...xdr.ContractEvent) xdr.TransactionResultMeta {
+		return xdr.TransactionResultMeta{TxApplyProcessing: xdr.TransactionMeta{
+			V:  3,
+			V3: &xdr.TransactionMetaV3{SorobanMeta: &xdr.SorobanTransactionMeta{Events: events}},
+		}}
+	}
+
+	actual, err := ContractEvents(xdr.LedgerCloseMeta{
+		V: 1,
+		V1: &xdr.LedgerCloseMetaV1{TxProcessing: []xdr.TransactionResultMeta{
+			sorobanMeta(event(1), event(2)),
+			// classic transaction
+			{TxApplyProcessing: xdr.TransactionMeta{V: 3, V3: &xdr.TransactionMetaV3{}}},
+			sorobanMeta(event(3)),
+		}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []xdr.ContractEvent{event(1), event(2), event(3)}, actual)
+
+	// ledgers closed before Soroban have no contract events
+	actual, err = ContractEvents(xdr.LedgerCloseMeta{
+		V: 0,
+		V0: &xdr.LedgerCloseMetaV0{TxProcessing: []xdr.TransactionResultMeta{
+			{TxApplyProcessing: xdr.TransactionMeta{V: 1, V1: &xdr.TransactionMetaV1{}}},
+		}},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, actual)
+
+	_, err = ContractEvents(xdr.LedgerCloseMeta{
+		V: 1,
+		V1: &xdr.LedgerCloseMetaV1{TxProcessing: []xdr.TransactionResultMeta{
+			{TxApplyProcessing: xdr.TransactionMeta{V: 3}},
+		}},
+	})
+	assert.EqualError(t, err, "TransactionMeta.V3 of transaction 0 is missing")
+
+	_, err = ContractEvents(xdr.LedgerCloseMeta{V: 2})
+	assert.EqualError(t, err, "unsupported LedgerCloseMeta.V: 2")
+}
+
+func TestUpgrades(t *testing.T) {
+	version := xdr.Uint32(21)
+	baseFee := xdr.Uint32(200)
+	upgrades := []xdr.UpgradeEntryMeta{
+		{Upgrade: xdr.LedgerUpgrade{Type: xdr.LedgerUpgradeTypeLedgerUpgradeVersion, NewLedgerVersion: &version}},
+		{Upgrade: xdr.LedgerUpgrade{Type: xdr.LedgerUpgradeTypeLedgerUpgradeBaseFee, NewBaseFee: &baseFee}},
+	}
+
+	actual, err := Upgrades(xdr.LedgerCloseMeta{
+		V:  0,
+		V0: &xdr.LedgerCloseMetaV0{UpgradesProcessing: upgrades},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, upgrades, actual)
+
+	actual, err = Upgrades(xdr.LedgerCloseMeta{
+		V:  1,
+		V1: &xdr.LedgerCloseMetaV1{UpgradesProcessing: upgrades},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, upgrades, actual)
+
+	actual, err = Upgrades(xdr.LedgerCloseMeta{V: 1, V1: &xdr.LedgerCloseMetaV1{}})
+	assert.NoError(t, err)
+	assert.Empty(t, actual)
+
+	_, err = Upgrades(xdr.LedgerCloseMeta{V: 1})
+	assert.EqualError(t, err, "LedgerCloseMeta.V1 is missing")
+
+	_, err = Upgrades(xdr.LedgerCloseMeta{V: 2})
+	assert.EqualError(t, err, "unsupported LedgerCloseMeta.V: 2")
+}
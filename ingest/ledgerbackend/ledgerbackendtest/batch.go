@@ -0,0 +1,43 @@
+package ledgerbackendtest
+
+import (
+	"bytes"
+
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/xdr"
+)
+
+// MakeSyntheticBatch returns a batch of count consecutive ledgers starting at
+// startSeq. Each ledger only has its header sequence set, which is enough for
+// the batch to be encoded, stored and served by a ledger backend. count must
+// be greater than 0.
+func MakeSyntheticBatch(startSeq, count uint32) xdr.LedgerCloseMetaBatch {
+	batch := xdr.LedgerCloseMetaBatch{
+		StartSequence:    xdr.Uint32(startSeq),
+		EndSequence:      xdr.Uint32(startSeq + count - 1),
+		LedgerCloseMetas: make([]xdr.LedgerCloseMeta, 0, count),
+	}
+	for sequence := startSeq; sequence-startSeq < count; sequence++ {
+		batch.LedgerCloseMetas = append(batch.LedgerCloseMetas, xdr.LedgerCloseMeta{
+			V: 0,
+			V0: &xdr.LedgerCloseMetaV0{
+				LedgerHeader: xdr.LedgerHeaderHistoryEntry{
+					Header: xdr.LedgerHeader{LedgerSeq: xdr.Uint32(sequence)},
+				},
+			},
+		})
+	}
+	return batch
+}
+
+// MakeSyntheticBatchObject returns MakeSyntheticBatch(startSeq, count) encoded
+// and compressed like the objects of a datastore, ready to be stored with
+// FakeDataStore.SetFile. It panics if the batch cannot be encoded.
+func MakeSyntheticBatchObject(startSeq, count uint32) []byte {
+	var buf bytes.Buffer
+	encoder := compressxdr.NewXDREncoder(compressxdr.DefaultCompressor, MakeSyntheticBatch(startSeq, count))
+	if _, err := encoder.WriteTo(&buf); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,38 @@
+package ledgerbackendtest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/xdr"
+)
+
+func TestMakeSyntheticBatch(t *testing.T) {
+	for _, count := range []uint32{1, 64} {
+		batch := MakeSyntheticBatch(10, count)
+		assert.Equal(t, xdr.Uint32(10), batch.StartSequence)
+		assert.Equal(t, xdr.Uint32(10+count-1), batch.EndSequence)
+		require.Len(t, batch.LedgerCloseMetas, int(count))
+		for i, lcm := range batch.LedgerCloseMetas {
+			assert.Equal(t, 10+uint32(i), lcm.LedgerSequence())
+		}
+
+		encoded, err := batch.MarshalBinary()
+		require.NoError(t, err)
+		var decoded xdr.LedgerCloseMetaBatch
+		require.NoError(t, decoded.UnmarshalBinary(encoded))
+		assert.Equal(t, batch, decoded)
+	}
+}
+
+func TestMakeSyntheticBatchObject(t *testing.T) {
+	var decoded xdr.LedgerCloseMetaBatch
+	decoder := compressxdr.NewXDRDecoder(compressxdr.DefaultCompressor, &decoded)
+	_, err := decoder.ReadFrom(bytes.NewReader(MakeSyntheticBatchObject(2, 8)))
+	require.NoError(t, err)
+	assert.Equal(t, MakeSyntheticBatch(2, 8), decoded)
+}
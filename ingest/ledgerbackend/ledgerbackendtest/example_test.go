@@ -0,0 +1,67 @@
+package ledgerbackendtest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stellar/go/ingest/ledgerbackend"
+	"github.com/stellar/go/ingest/ledgerbackend/ledgerbackendtest"
+	"github.com/stellar/go/support/datastore"
+)
+
+// This example verifies that BufferedStorageBackend retries transient
+// download failures before handing the ledger to the caller.
+func ExampleFakeDataStore_FailNext() {
+	schema := datastore.DataStoreSchema{LedgersPerFile: 1, FilesPerPartition: 10}
+	store := ledgerbackendtest.NewFakeDataStore(schema)
+
+	key := schema.GetObjectKeyFromSequenceNumber(3)
+	store.SetFile(key, ledgerbackendtest.MakeSyntheticBatchObject(3, 1))
+	store.FailNext(key, errors.New("transient error"), errors.New("transient error"))
+
+	backend, err := ledgerbackend.NewBufferedStorageBackend(ledgerbackend.BufferedStorageBackendConfig{
+		BufferSize: 1,
+		NumWorkers: 1,
+		RetryLimit: 3,
+		RetryWait:  time.Millisecond,
+	}, store)
+	if err != nil {
+		panic(err)
+	}
+	defer backend.Close()
+
+	ctx := context.Background()
+	if err = backend.PrepareRange(ctx, ledgerbackend.SingleLedgerRange(3)); err != nil {
+		panic(err)
+	}
+	lcm, err := backend.GetLedger(ctx, 3)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println("ledger:", lcm.LedgerSequence())
+	fmt.Println("downloads:", store.Calls(key))
+	// Output:
+	// ledger: 3
+	// downloads: 3
+}
+
+// This example shows that injected latency honors the caller's context.
+func ExampleFakeDataStore_SetLatency() {
+	schema := datastore.DataStoreSchema{LedgersPerFile: 1, FilesPerPartition: 10}
+	store := ledgerbackendtest.NewFakeDataStore(schema)
+
+	key := schema.GetObjectKeyFromSequenceNumber(3)
+	store.SetFile(key, ledgerbackendtest.MakeSyntheticBatchObject(3, 1))
+	store.SetLatency(key, time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	_, err := store.GetFile(ctx, key)
+	fmt.Println(errors.Is(err, context.DeadlineExceeded))
+	// Output:
+	// true
+}
@@ -0,0 +1,184 @@
+// Package ledgerbackendtest provides helpers for testing code built on top of
+// the ledgerbackend package, such as an in-memory DataStore whose reads can be
+// slowed down or made to fail on demand.
+package ledgerbackendtest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/support/datastore"
+)
+
+// Ensure FakeDataStore implements DataStore
+var _ datastore.DataStore = (*FakeDataStore)(nil)
+
+// FakeDataStore is an in-memory datastore.DataStore intended for tests.
+// Reads of individual keys can be delayed with SetLatency or made to fail
+// with FailNext, and every GetFile call is counted per key.
+// FakeDataStore is safe for concurrent use.
+type FakeDataStore struct {
+	lock     sync.Mutex
+	schema   datastore.DataStoreSchema
+	files    map[string][]byte
+	metadata map[string]map[string]string
+	latency  map[string]time.Duration
+	failures map[string][]error
+	calls    map[string]int
+}
+
+// NewFakeDataStore returns an empty FakeDataStore using the given schema.
+func NewFakeDataStore(schema datastore.DataStoreSchema) *FakeDataStore {
+	return &FakeDataStore{
+		schema:   schema,
+		files:    map[string][]byte{},
+		metadata: map[string]map[string]string{},
+		latency:  map[string]time.Duration{},
+		failures: map[string][]error{},
+		calls:    map[string]int{},
+	}
+}
+
+// SetFile stores the given contents under key, replacing any existing object.
+func (f *FakeDataStore) SetFile(key string, contents []byte) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.files[key] = contents
+}
+
+// SetLatency delays every subsequent GetFile call for key by d.
+// The delay is interrupted if the caller's context is done.
+func (f *FakeDataStore) SetLatency(key string, d time.Duration) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.latency[key] = d
+}
+
+// FailNext queues errors to be returned by the next GetFile calls for key,
+// one error per call. Once the queue is drained GetFile behaves normally.
+func (f *FakeDataStore) FailNext(key string, errs ...error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.failures[key] = append(f.failures[key], errs...)
+}
+
+// Calls returns how many times GetFile has been invoked for key.
+func (f *FakeDataStore) Calls(key string) int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.calls[key]
+}
+
+// TotalCalls returns how many times GetFile has been invoked for any key.
+func (f *FakeDataStore) TotalCalls() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	total := 0
+	for _, count := range f.calls {
+		total += count
+	}
+	return total
+}
+
+// GetFile returns the contents stored under path, after applying any
+// configured latency and queued failures. It returns os.ErrNotExist for
+// unknown keys.
+func (f *FakeDataStore) GetFile(ctx context.Context, path string) (io.ReadCloser, error) {
+	f.lock.Lock()
+	f.calls[path]++
+	delay := f.latency[path]
+	var injected error
+	if queued := f.failures[path]; len(queued) > 0 {
+		injected = queued[0]
+		f.failures[path] = queued[1:]
+	}
+	contents, ok := f.files[path]
+	f.lock.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if injected != nil {
+		return nil, injected
+	}
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return io.NopCloser(bytes.NewReader(contents)), nil
+}
+
+// GetFileMetadata returns the metadata stored alongside path.
+func (f *FakeDataStore) GetFileMetadata(ctx context.Context, path string) (map[string]string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if _, ok := f.files[path]; !ok {
+		return nil, os.ErrNotExist
+	}
+	return f.metadata[path], nil
+}
+
+// PutFile stores the contents written by in under path.
+func (f *FakeDataStore) PutFile(ctx context.Context, path string, in io.WriterTo, metaData map[string]string) error {
+	buf := &bytes.Buffer{}
+	if _, err := in.WriteTo(buf); err != nil {
+		return err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.files[path] = buf.Bytes()
+	f.metadata[path] = metaData
+	return nil
+}
+
+// PutFileIfNotExists stores the contents written by in under path unless
+// an object already exists there.
+func (f *FakeDataStore) PutFileIfNotExists(ctx context.Context, path string, in io.WriterTo, metaData map[string]string) (bool, error) {
+	if exists, _ := f.Exists(ctx, path); exists {
+		return false, nil
+	}
+	if err := f.PutFile(ctx, path, in, metaData); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Exists reports whether an object is stored under path.
+func (f *FakeDataStore) Exists(ctx context.Context, path string) (bool, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	_, ok := f.files[path]
+	return ok, nil
+}
+
+// Size returns the length of the object stored under path.
+func (f *FakeDataStore) Size(ctx context.Context, path string) (int64, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	contents, ok := f.files[path]
+	if !ok {
+		return 0, os.ErrNotExist
+	}
+	return int64(len(contents)), nil
+}
+
+// GetSchema returns the schema the FakeDataStore was created with.
+func (f *FakeDataStore) GetSchema() datastore.DataStoreSchema {
+	return f.schema
+}
+
+// Close is a no-op.
+func (f *FakeDataStore) Close() error {
+	return nil
+}
@@ -14,6 +14,9 @@ func WithMetrics(base LedgerBackend, registry *prometheus.Registry, namespace st
 	if captiveCoreBackend, ok := base.(*CaptiveStellarCore); ok {
 		captiveCoreBackend.registerMetrics(registry, namespace)
 	}
+	if bufferedStorageBackend, ok := base.(*BufferedStorageBackend); ok {
+		bufferedStorageBackend.registerMetrics(registry, namespace)
+	}
 	summary := prometheus.NewSummary(
 		prometheus.SummaryOpts{
 			Namespace: namespace, Subsystem: "ingest", Name: "ledger_fetch_duration_seconds",
@@ -42,3 +45,62 @@ func (m metricsLedgerBackend) GetLedger(ctx context.Context, sequence uint32) (x
 	m.ledgerFetchDurationSummary.Observe(time.Since(startTime).Seconds())
 	return lcm, nil
 }
+
+// bufferedStorageMetrics break down the time BufferedStorageBackend spends on
+// every ledger object. Each summary is nil unless registered by WithMetrics.
+type bufferedStorageMetrics struct {
+	downloadDuration     prometheus.Summary
+	networkCheckDuration prometheus.Summary
+	decompressDuration   prometheus.Summary
+	unmarshalDuration    prometheus.Summary
+
+	compressedBytes   prometheus.Counter
+	decompressedBytes prometheus.Counter
+}
+
+func (bsb *BufferedStorageBackend) registerMetrics(registry *prometheus.Registry, namespace string) {
+	newSummary := func(name, help string) prometheus.Summary {
+		return prometheus.NewSummary(prometheus.SummaryOpts{
+			Namespace: namespace, Subsystem: "ingest", Name: name, Help: help,
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		})
+	}
+	metrics := bufferedStorageMetrics{
+		downloadDuration: newSummary("buffered_storage_backend_download_duration_seconds",
+			"duration of downloading a ledger object from the datastore, sliding window = 10m"),
+		networkCheckDuration: newSummary("buffered_storage_backend_network_check_duration_seconds",
+			"duration of checking the network passphrase in the metadata of a ledger object, sliding window = 10m"),
+		decompressDuration: newSummary("buffered_storage_backend_decompress_duration_seconds",
+			"duration of decompressing a ledger object, sliding window = 10m"),
+		unmarshalDuration: newSummary("buffered_storage_backend_unmarshal_duration_seconds",
+			"duration of unmarshalling the xdr of a ledger object, sliding window = 10m"),
+	}
+	metrics.compressedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "ingest", Name: "buffered_storage_backend_compressed_bytes_total",
+		Help: "total size of the ledger objects downloaded from the datastore",
+	})
+	metrics.decompressedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "ingest", Name: "buffered_storage_backend_decompressed_bytes_total",
+		Help: "total size of the ledger objects downloaded from the datastore after decompression",
+	})
+	registry.MustRegister(
+		metrics.downloadDuration, metrics.networkCheckDuration, metrics.decompressDuration, metrics.unmarshalDuration,
+		metrics.compressedBytes, metrics.decompressedBytes,
+	)
+
+	bsb.bsBackendLock.Lock()
+	defer bsb.bsBackendLock.Unlock()
+	bsb.metrics = metrics
+}
+
+func addBytes(counter prometheus.Counter, n int) {
+	if counter != nil {
+		counter.Add(float64(n))
+	}
+}
+
+func observeDuration(summary prometheus.Summary, startTime time.Time) {
+	if summary != nil {
+		summary.Observe(time.Since(startTime).Seconds())
+	}
+}
@@ -3,6 +3,12 @@ package ledgerbackend
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/stellar/go/support/datastore"
 )
 
 // Range represents a range of ledger sequence numbers.
@@ -56,6 +62,90 @@ func (r Range) Contains(other Range) bool {
 	return r.from <= other.from
 }
 
+// Count returns the number of ledgers in a bounded range. It returns 0 for
+// unbounded ranges, as well as for [0,math.MaxUint32] whose count does not fit
+// in a uint32.
+func (r Range) Count() uint32 {
+	if !r.bounded || r.from > r.to {
+		return 0
+	}
+	return r.to - r.from + 1
+}
+
+// Intersect returns the ledgers present in both r and other. The returned bool
+// is false when the ranges do not overlap.
+func (r Range) Intersect(other Range) (Range, bool) {
+	intersection := Range{from: max(r.from, other.from)}
+	switch {
+	case r.bounded && other.bounded:
+		intersection.to, intersection.bounded = min(r.to, other.to), true
+	case r.bounded:
+		intersection.to, intersection.bounded = r.to, true
+	case other.bounded:
+		intersection.to, intersection.bounded = other.to, true
+	}
+	if intersection.bounded && intersection.from > intersection.to {
+		return Range{}, false
+	}
+	return intersection, true
+}
+
+// Subtract returns the ledgers of r which are not in other, as at most two
+// ranges in ascending order.
+func (r Range) Subtract(other Range) []Range {
+	intersection, ok := r.Intersect(other)
+	if !ok {
+		return []Range{r}
+	}
+
+	var remaining []Range
+	if r.from < intersection.from {
+		remaining = append(remaining, BoundedRange(r.from, intersection.from-1))
+	}
+	if intersection.bounded && intersection.to < math.MaxUint32 {
+		if !r.bounded {
+			remaining = append(remaining, UnboundedRange(intersection.to+1))
+		} else if intersection.to < r.to {
+			remaining = append(remaining, BoundedRange(intersection.to+1, r.to))
+		}
+	}
+	return remaining
+}
+
+// requireBounded returns an error if r is unbounded. Operations which load,
+// check or export a whole range use it to reject unbounded ranges, which they
+// would never complete, while streaming operations such as
+// BufferedStorageBackend.ForEachLedger accept them.
+func requireBounded(operation string, r Range) error {
+	if !r.bounded {
+		return errors.Errorf("%s requires a bounded range, got %s", operation, r)
+	}
+	return nil
+}
+
+// Partitions returns the names of the partition directories holding the ledgers
+// of a bounded range in a datastore with the given layout, in ascending ledger
+// order. It returns nil for unbounded ranges and for layouts without partitions.
+func (r Range) Partitions(filesPerPartition, ledgersPerFile uint32) []string {
+	if !r.bounded || filesPerPartition <= 1 || ledgersPerFile == 0 {
+		return nil
+	}
+
+	schema := datastore.DataStoreSchema{LedgersPerFile: ledgersPerFile, FilesPerPartition: filesPerPartition}
+	var partitions []string
+	for sequence := uint64(r.from); sequence <= uint64(r.to); {
+		_, end := datastore.PartitionForSequence(uint32(sequence), ledgersPerFile, filesPerPartition)
+		objectKey := schema.GetObjectKeyFromSequenceNumber(uint32(sequence))
+		partitions = append(partitions, objectKey[:strings.Index(objectKey, "/")])
+		if end < uint32(sequence) {
+			// the last partition overflows uint32
+			break
+		}
+		sequence = uint64(end) + 1
+	}
+	return partitions
+}
+
 // SingleLedgerRange constructs a bounded range containing a single ledger.
 func SingleLedgerRange(ledger uint32) Range {
 	return Range{from: ledger, to: ledger, bounded: true}
@@ -66,6 +156,19 @@ func BoundedRange(from uint32, to uint32) Range {
 	return Range{from: from, to: to, bounded: true}
 }
 
+// RangeFromCount constructs a bounded range of count ledgers starting at from.
+// It returns an error if count is 0 or if the range would go past
+// math.MaxUint32.
+func RangeFromCount(from, count uint32) (Range, error) {
+	if count == 0 {
+		return Range{}, errors.New("count must be > 0")
+	}
+	if count-1 > math.MaxUint32-from {
+		return Range{}, errors.Errorf("range of %d ledgers starting at %d overflows", count, from)
+	}
+	return BoundedRange(from, from+count-1), nil
+}
+
 // BoundedRange constructs a unbounded range of ledgers with a fixed starting ledger.
 func UnboundedRange(from uint32) Range {
 	return Range{from: from, bounded: false}
@@ -0,0 +1,122 @@
+package ledgerbackend
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangePartitions(t *testing.T) {
+	// single partition
+	assert.Equal(t,
+		[]string{"FFFFFFFF--0-639"},
+		BoundedRange(2, 639).Partitions(10, 64))
+	assert.Equal(t,
+		[]string{"FFFFFD7F--640-1279"},
+		SingleLedgerRange(1000).Partitions(10, 64))
+
+	// several partitions
+	assert.Equal(t,
+		[]string{"FFFFFFFF--0-639", "FFFFFD7F--640-1279", "FFFFFAFF--1280-1919"},
+		BoundedRange(600, 1280).Partitions(10, 64))
+	assert.Equal(t,
+		[]string{"FFFFFFFF--0-63999", "FFFF05FF--64000-127999"},
+		BoundedRange(63999, 64000).Partitions(64000, 1))
+
+	// the last partition ends past math.MaxUint32
+	assert.Len(t, BoundedRange(math.MaxUint32-1, math.MaxUint32).Partitions(10, 64), 1)
+
+	// no partitions
+	assert.Nil(t, UnboundedRange(2).Partitions(10, 64))
+	assert.Nil(t, BoundedRange(2, 100).Partitions(1, 64))
+}
+
+func TestRangeIntersect(t *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		a, b     Range
+		expected Range
+		ok       bool
+	}{
+		{"overlapping", BoundedRange(2, 10), BoundedRange(5, 20), BoundedRange(5, 10), true},
+		{"containing", BoundedRange(2, 20), BoundedRange(5, 10), BoundedRange(5, 10), true},
+		{"touching", BoundedRange(2, 10), BoundedRange(10, 20), SingleLedgerRange(10), true},
+		{"disjoint", BoundedRange(2, 10), BoundedRange(11, 20), Range{}, false},
+		{"bounded and unbounded", BoundedRange(2, 10), UnboundedRange(5), BoundedRange(5, 10), true},
+		{"bounded before unbounded", BoundedRange(2, 10), UnboundedRange(11), Range{}, false},
+		{"both unbounded", UnboundedRange(2), UnboundedRange(5), UnboundedRange(5), true},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, ok := testCase.a.Intersect(testCase.b)
+			assert.Equal(t, testCase.ok, ok)
+			assert.Equal(t, testCase.expected, actual)
+
+			// intersection is commutative
+			actual, ok = testCase.b.Intersect(testCase.a)
+			assert.Equal(t, testCase.ok, ok)
+			assert.Equal(t, testCase.expected, actual)
+		})
+	}
+}
+
+func TestRangeSubtract(t *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		a, b     Range
+		expected []Range
+	}{
+		{"overlapping start", BoundedRange(2, 10), BoundedRange(1, 5), []Range{BoundedRange(6, 10)}},
+		{"overlapping end", BoundedRange(2, 10), BoundedRange(5, 20), []Range{BoundedRange(2, 4)}},
+		{"contained", BoundedRange(2, 20), BoundedRange(5, 10), []Range{BoundedRange(2, 4), BoundedRange(11, 20)}},
+		{"containing", BoundedRange(5, 10), BoundedRange(2, 20), nil},
+		{"equal", BoundedRange(5, 10), BoundedRange(5, 10), nil},
+		{"disjoint", BoundedRange(2, 10), BoundedRange(11, 20), []Range{BoundedRange(2, 10)}},
+		{"unbounded minus bounded", UnboundedRange(2), BoundedRange(5, 10), []Range{BoundedRange(2, 4), UnboundedRange(11)}},
+		{"unbounded minus unbounded", UnboundedRange(2), UnboundedRange(5), []Range{BoundedRange(2, 4)}},
+		{"bounded minus unbounded", BoundedRange(2, 10), UnboundedRange(5), []Range{BoundedRange(2, 4)}},
+		{"unbounded minus everything", UnboundedRange(5), UnboundedRange(2), nil},
+		{"unbounded minus range ending at max", UnboundedRange(2), BoundedRange(5, math.MaxUint32), []Range{BoundedRange(2, 4)}},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, testCase.a.Subtract(testCase.b))
+		})
+	}
+}
+
+func TestRangeFromCount(t *testing.T) {
+	for _, testCase := range []struct {
+		from, count uint32
+		expected    Range
+	}{
+		{2, 1, SingleLedgerRange(2)},
+		{2, 64, BoundedRange(2, 65)},
+		{0, math.MaxUint32, BoundedRange(0, math.MaxUint32-1)},
+		{1, math.MaxUint32, BoundedRange(1, math.MaxUint32)},
+	} {
+		ledgerRange, err := RangeFromCount(testCase.from, testCase.count)
+		require.NoError(t, err)
+		assert.Equal(t, testCase.expected, ledgerRange)
+		assert.Equal(t, testCase.count, ledgerRange.Count())
+	}
+
+	_, err := RangeFromCount(2, 0)
+	assert.EqualError(t, err, "count must be > 0")
+	_, err = RangeFromCount(math.MaxUint32, 2)
+	assert.EqualError(t, err, "range of 2 ledgers starting at 4294967295 overflows")
+}
+
+func TestRangeCount(t *testing.T) {
+	assert.Equal(t, uint32(1), SingleLedgerRange(5).Count())
+	assert.Equal(t, uint32(10), BoundedRange(2, 11).Count())
+	assert.Equal(t, uint32(0), UnboundedRange(2).Count())
+	assert.Equal(t, uint32(0), BoundedRange(0, math.MaxUint32).Count())
+}
+
+func TestRequireBounded(t *testing.T) {
+	assert.NoError(t, requireBounded("GetLedgers", BoundedRange(2, 10)))
+	assert.NoError(t, requireBounded("GetLedgers", SingleLedgerRange(2)))
+	assert.EqualError(t, requireBounded("GetLedgers", UnboundedRange(2)),
+		"GetLedgers requires a bounded range, got [2,latest)")
+}
@@ -0,0 +1,97 @@
+package ledgerbackend
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/support/datastore"
+	"github.com/stellar/go/xdr"
+)
+
+// ReExport reads the ledgers in ledgerRange from src and writes them to dst,
+// grouped into objects according to the schema of dst. It can be used to
+// migrate ledgers between datastores using a different number of ledgers per
+// file. Existing objects in dst are overwritten. The first and last objects
+// only hold the part of their batch which lies inside ledgerRange.
+// compressionLevel is the zstd level of the written objects, 0 selects the
+// default level.
+func ReExport(ctx context.Context, src LedgerBackend, dst datastore.DataStore, ledgerRange Range, networkPassphrase string, compressionLevel int) error {
+	if err := requireBounded("ReExport", ledgerRange); err != nil {
+		return err
+	}
+	compressor, err := compressxdr.NewZstdCompressor(compressionLevel)
+	if err != nil {
+		return err
+	}
+
+	if err := src.PrepareRange(ctx, ledgerRange); err != nil {
+		return errors.Wrap(err, "error preparing source range")
+	}
+
+	schema := dst.GetSchema()
+	var batch xdr.LedgerCloseMetaBatch
+	for sequence := ledgerRange.from; sequence <= ledgerRange.to; sequence++ {
+		ledgerCloseMeta, err := src.GetLedger(ctx, sequence)
+		if err != nil {
+			return errors.Wrapf(err, "error getting ledger %d", sequence)
+		}
+
+		if len(batch.LedgerCloseMetas) == 0 {
+			batch = xdr.LedgerCloseMetaBatch{
+				StartSequence: xdr.Uint32(sequence),
+				EndSequence:   xdr.Uint32(min(schema.GetSequenceNumberEndBoundary(sequence), ledgerRange.to)),
+			}
+		}
+		if err = batch.AddLedger(ledgerCloseMeta); err != nil {
+			return errors.Wrapf(err, "error adding ledger %d", sequence)
+		}
+
+		if sequence == uint32(batch.EndSequence) {
+			if err = writeBatch(ctx, dst, compressor, batch, networkPassphrase); err != nil {
+				return err
+			}
+			batch = xdr.LedgerCloseMetaBatch{}
+		}
+	}
+
+	return nil
+}
+
+// WriteLedgerBatch writes batch to dst under the key given by the schema of dst,
+// with the metadata written by the exporter. The batch must fit in a single
+// object of dst. It can be used to write fixtures for tests reading from a
+// datastore.
+func WriteLedgerBatch(ctx context.Context, dst datastore.DataStore, batch xdr.LedgerCloseMetaBatch, networkPassphrase string) error {
+	if len(batch.LedgerCloseMetas) == 0 {
+		return errors.New("batch is empty")
+	}
+	start := uint32(batch.StartSequence)
+	if end := dst.GetSchema().GetSequenceNumberEndBoundary(start); uint32(batch.EndSequence) > end {
+		return errors.Errorf("batch [%d,%d] does not fit in the object holding ledgers up to %d",
+			start, batch.EndSequence, end)
+	}
+	return writeBatch(ctx, dst, compressxdr.DefaultCompressor, batch, networkPassphrase)
+}
+
+func writeBatch(ctx context.Context, dst datastore.DataStore, compressor compressxdr.Compressor, batch xdr.LedgerCloseMetaBatch, networkPassphrase string) error {
+	first := batch.LedgerCloseMetas[0]
+	last := batch.LedgerCloseMetas[len(batch.LedgerCloseMetas)-1]
+	metaData := datastore.MetaData{
+		StartLedger:          uint32(batch.StartSequence),
+		EndLedger:            uint32(batch.EndSequence),
+		StartLedgerCloseTime: first.LedgerCloseTime(),
+		EndLedgerCloseTime:   last.LedgerCloseTime(),
+		ProtocolVersion:      last.ProtocolVersion(),
+		NetworkPassPhrase:    networkPassphrase,
+		CompressionType:      compressor.Name(),
+	}
+
+	objectKey := dst.GetSchema().GetObjectKeyFromSequenceNumber(uint32(batch.StartSequence))
+	encoder := compressxdr.NewXDREncoder(compressor, &batch)
+	if err := dst.PutFile(ctx, objectKey, encoder, metaData.ToMap()); err != nil {
+		return errors.Wrapf(err, "error writing %s", objectKey)
+	}
+	return nil
+}
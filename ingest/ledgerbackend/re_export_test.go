@@ -0,0 +1,111 @@
+package ledgerbackend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/ingest/ledgerbackend/ledgerbackendtest"
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/support/datastore"
+	"github.com/stellar/go/xdr"
+)
+
+func TestReExport(t *testing.T) {
+	ctx := context.Background()
+	src := createBufferedStorageBackendForTesting()
+	src.dataStore = createFakeDataStore(t, 2, 21, 1)
+
+	dstSchema := datastore.DataStoreSchema{LedgersPerFile: 10, FilesPerPartition: partitionSize}
+	dst := ledgerbackendtest.NewFakeDataStore(dstSchema)
+	require.NoError(t, ReExport(ctx, &src, dst, BoundedRange(2, 21), "test passphrase", 0))
+	require.NoError(t, src.Close())
+
+	for _, sequence := range []uint32{2, 10, 20} {
+		exists, err := dst.Exists(ctx, dstSchema.GetObjectKeyFromSequenceNumber(sequence))
+		require.NoError(t, err)
+		assert.True(t, exists, "object containing ledger %d", sequence)
+	}
+	metaData, err := dst.GetFileMetadata(ctx, dstSchema.GetObjectKeyFromSequenceNumber(20))
+	require.NoError(t, err)
+	assert.Equal(t, "20", metaData["start-ledger"])
+	assert.Equal(t, "21", metaData["end-ledger"])
+	assert.Equal(t, "test passphrase", metaData["network-passphrase"])
+
+	reader := createBufferedStorageBackendForTesting()
+	reader.dataStore = dst
+	var sequences []uint32
+	require.NoError(t, reader.ForEachLedger(ctx, BoundedRange(2, 21), func(lcm xdr.LedgerCloseMeta) error {
+		sequences = append(sequences, lcm.LedgerSequence())
+		return nil
+	}))
+	expected := make([]uint32, 0, 20)
+	for sequence := uint32(2); sequence <= 21; sequence++ {
+		expected = append(expected, sequence)
+	}
+	assert.Equal(t, expected, sequences)
+	assert.Equal(t, 3, dst.TotalCalls())
+	assert.NoError(t, reader.Close())
+}
+
+func TestReExportRequiresBoundedRange(t *testing.T) {
+	src := createBufferedStorageBackendForTesting()
+	dst := ledgerbackendtest.NewFakeDataStore(datastore.DataStoreSchema{LedgersPerFile: 10})
+	assert.EqualError(t, ReExport(context.Background(), &src, dst, UnboundedRange(2), "", 0), "ReExport requires a bounded range, got [2,latest)")
+}
+
+func TestReExportCompressionLevel(t *testing.T) {
+	ctx := context.Background()
+	dstSchema := datastore.DataStoreSchema{LedgersPerFile: 20, FilesPerPartition: partitionSize}
+	sizes := map[int]int64{}
+	for _, level := range []int{compressxdr.ZstdBestSpeed, compressxdr.ZstdBestCompression} {
+		src := createBufferedStorageBackendForTesting()
+		src.dataStore = createFakeDataStore(t, 2, 21, 1)
+		dst := ledgerbackendtest.NewFakeDataStore(dstSchema)
+		require.NoError(t, ReExport(ctx, &src, dst, BoundedRange(2, 21), "", level))
+		require.NoError(t, src.Close())
+
+		size, err := dst.Size(ctx, dstSchema.GetObjectKeyFromSequenceNumber(2))
+		require.NoError(t, err)
+		sizes[level] = size
+
+		reader := createBufferedStorageBackendForTesting()
+		reader.dataStore = dst
+		ledgers, err := reader.GetLedgerWindow(ctx, 2, 0, 19)
+		require.NoError(t, err)
+		assert.Equal(t, createLCMForTesting(2, 21), ledgers)
+		assert.NoError(t, reader.Close())
+	}
+	assert.Less(t, sizes[compressxdr.ZstdBestCompression], sizes[compressxdr.ZstdBestSpeed])
+
+	src := createBufferedStorageBackendForTesting()
+	dst := ledgerbackendtest.NewFakeDataStore(dstSchema)
+	assert.EqualError(t, ReExport(ctx, &src, dst, BoundedRange(2, 21), "", 23),
+		"invalid zstd compression level 23, must be between 1 and 22")
+}
+
+func TestWriteLedgerBatch(t *testing.T) {
+	ctx := context.Background()
+	schema := datastore.DataStoreSchema{LedgersPerFile: 4, FilesPerPartition: partitionSize}
+	dst := ledgerbackendtest.NewFakeDataStore(schema)
+
+	require.NoError(t, WriteLedgerBatch(ctx, dst, createTestLedgerCloseMetaBatch(4, 7, 4), "test passphrase"))
+	require.NoError(t, WriteLedgerBatch(ctx, dst, createTestLedgerCloseMetaBatch(8, 11, 4), "test passphrase"))
+	metaData, err := dst.GetFileMetadata(ctx, schema.GetObjectKeyFromSequenceNumber(8))
+	require.NoError(t, err)
+	assert.Equal(t, "test passphrase", metaData["network-passphrase"])
+
+	reader := createBufferedStorageBackendForTesting()
+	reader.dataStore = dst
+	ledgers, err := reader.GetLedgerWindow(ctx, 4, 0, 7)
+	require.NoError(t, err)
+	assert.Equal(t, createLCMForTesting(4, 11), ledgers)
+	assert.NoError(t, reader.Close())
+
+	assert.EqualError(t, WriteLedgerBatch(ctx, dst, createTestLedgerCloseMetaBatch(6, 9, 4), ""),
+		"batch [6,9] does not fit in the object holding ledgers up to 7")
+	assert.EqualError(t, WriteLedgerBatch(ctx, dst, xdr.LedgerCloseMetaBatch{StartSequence: 4, EndSequence: 7}, ""),
+		"batch is empty")
+}
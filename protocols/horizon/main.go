@@ -10,6 +10,7 @@ import (
 	"math/big"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/stellar/go/protocols/horizon/base"
@@ -871,6 +872,49 @@ type LiquidityPoolReserve struct {
 	Amount string `json:"amount"`
 }
 
+// FilterFieldError describes a single invalid field of a filter config.
+type FilterFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FilterValidationError is returned when a filter config fails validation.
+// It reports every offending field so that clients can give per-field feedback.
+type FilterValidationError struct {
+	Fields []FilterFieldError `json:"fields"`
+}
+
+func (e *FilterValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+func validateFilterConfig(whitelist []string, enabled *bool) error {
+	validationErr := &FilterValidationError{}
+
+	if whitelist == nil {
+		validationErr.Fields = append(validationErr.Fields, FilterFieldError{
+			Field:   "whitelist",
+			Message: "missing required whitelist",
+		})
+	}
+
+	if enabled == nil {
+		validationErr.Fields = append(validationErr.Fields, FilterFieldError{
+			Field:   "enabled",
+			Message: "missing required enabled",
+		})
+	}
+
+	if len(validationErr.Fields) > 0 {
+		return validationErr
+	}
+	return nil
+}
+
 type AssetFilterConfig struct {
 	Whitelist    []string `json:"whitelist"`
 	Enabled      *bool    `json:"enabled"`
@@ -891,12 +935,8 @@ func (f *AccountFilterConfig) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	if config.Whitelist == nil {
-		return errors.New("missing required whitelist")
-	}
-
-	if config.Enabled == nil {
-		return errors.New("missing required enabled")
+	if err := validateFilterConfig(config.Whitelist, config.Enabled); err != nil {
+		return err
 	}
 
 	*f = AccountFilterConfig(config)
@@ -911,12 +951,8 @@ func (f *AssetFilterConfig) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	if config.Whitelist == nil {
-		return errors.New("missing required whitelist")
-	}
-
-	if config.Enabled == nil {
-		return errors.New("missing required enabled")
+	if err := validateFilterConfig(config.Whitelist, config.Enabled); err != nil {
+		return err
 	}
 
 	*f = AssetFilterConfig(config)
@@ -256,3 +256,27 @@ func TestMustKeyTypeFromAddress(t *testing.T) {
 		assert.Equal(t, MustKeyTypeFromAddress(test.address), test.keyType)
 	}
 }
+
+func TestFilterConfigValidationReportsAllFields(t *testing.T) {
+	var accountConfig AccountFilterConfig
+	err := json.Unmarshal([]byte(`{"whitelist": ["GABC"]}`), &accountConfig)
+	var validationErr *FilterValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []FilterFieldError{
+		{Field: "enabled", Message: "missing required enabled"},
+	}, validationErr.Fields)
+	assert.EqualError(t, err, "missing required enabled")
+
+	var assetConfig AssetFilterConfig
+	err = json.Unmarshal([]byte(`{}`), &assetConfig)
+	assert.ErrorAs(t, err, &validationErr)
+	encoded, err := json.Marshal(validationErr)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"fields": [
+		{"field": "whitelist", "message": "missing required whitelist"},
+		{"field": "enabled", "message": "missing required enabled"}
+	]}`, string(encoded))
+
+	err = json.Unmarshal([]byte(`{"whitelist": ["GABC"], "enabled": true}`), &assetConfig)
+	assert.NoError(t, err)
+}
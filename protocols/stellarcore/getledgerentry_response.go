@@ -1,5 +1,13 @@
 package stellarcore
 
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/stellar/go/xdr"
+)
+
 const (
 	// LiveState represents the state value returned by stellar-core when a
 	// ledger entry is live
@@ -16,3 +24,189 @@ type GetLedgerEntryResponse struct {
 	Entry  string `json:"entry"`
 	Ledger int64  `json:"ledger"`
 }
+
+// Validate checks that the response is consistent: the state is known, a live
+// response includes its entry and the ledger at which the state was read is
+// set. It returns an error describing the first violation. The entry itself is
+// not decoded.
+func (r GetLedgerEntryResponse) Validate() error {
+	switch r.State {
+	case LiveState:
+		if r.Entry == "" {
+			return fmt.Errorf("live ledger entry response is missing the entry")
+		}
+	case DeadState:
+	default:
+		return fmt.Errorf("unknown ledger entry state %q", r.State)
+	}
+	if r.Ledger <= 0 {
+		return fmt.Errorf("invalid ledger %d in ledger entry response", r.Ledger)
+	}
+	return nil
+}
+
+// LedgerEntryState is the typed form of GetLedgerEntryResponse.State
+type LedgerEntryState int
+
+const (
+	// LedgerEntryLive means the ledger entry exists
+	LedgerEntryLive LedgerEntryState = iota
+	// LedgerEntryDead means the ledger entry does not exist
+	LedgerEntryDead
+)
+
+func (s LedgerEntryState) String() string {
+	switch s {
+	case LedgerEntryLive:
+		return LiveState
+	case LedgerEntryDead:
+		return DeadState
+	default:
+		return fmt.Sprintf("LedgerEntryState(%d)", int(s))
+	}
+}
+
+// DecodedLedgerEntryResponse is a GetLedgerEntryResponse with its entry decoded
+type DecodedLedgerEntryResponse struct {
+	State LedgerEntryState
+	// Entry, Key and Type are only set when the entry is included in the response
+	Entry *xdr.LedgerEntry
+	Key   *xdr.LedgerKey
+	Type  xdr.LedgerEntryType
+	// LastModifiedLedger is the ledger in which a live entry was last modified
+	LastModifiedLedger uint32
+	// Ledger is the ledger at which the state was read
+	Ledger int64
+}
+
+// ToDecoded decodes the base64 XDR entry of the response, derives its key and
+// parses the state. A live response must include an entry.
+func (r GetLedgerEntryResponse) ToDecoded() (DecodedLedgerEntryResponse, error) {
+	decoded := DecodedLedgerEntryResponse{Ledger: r.Ledger}
+	switch r.State {
+	case LiveState:
+		decoded.State = LedgerEntryLive
+		if r.Entry == "" {
+			return DecodedLedgerEntryResponse{}, fmt.Errorf("live ledger entry response is missing the entry")
+		}
+	case DeadState:
+		decoded.State = LedgerEntryDead
+	default:
+		return DecodedLedgerEntryResponse{}, fmt.Errorf("unknown ledger entry state %q", r.State)
+	}
+
+	if r.Entry == "" {
+		return decoded, nil
+	}
+
+	var entry xdr.LedgerEntry
+	if err := xdr.SafeUnmarshalBase64(r.Entry, &entry); err != nil {
+		return DecodedLedgerEntryResponse{}, fmt.Errorf("could not decode ledger entry: %w", err)
+	}
+	key, err := entry.LedgerKey()
+	if err != nil {
+		return DecodedLedgerEntryResponse{}, fmt.Errorf("could not derive ledger key: %w", err)
+	}
+	decoded.Entry = &entry
+	decoded.Key = &key
+	decoded.Type = entry.Data.Type
+	decoded.LastModifiedLedger = uint32(entry.LastModifiedLedgerSeq)
+	return decoded, nil
+}
+
+// EntriesByLastModified decodes the entries of the given responses and groups
+// them by the ledger in which they were last modified, keeping the order of
+// the responses within each group. Responses for dead entries are skipped.
+func EntriesByLastModified(responses []GetLedgerEntryResponse) (map[uint32][]xdr.LedgerEntry, error) {
+	entries := map[uint32][]xdr.LedgerEntry{}
+	for i, response := range responses {
+		decoded, err := response.ToDecoded()
+		if err != nil {
+			return nil, fmt.Errorf("response %d: %w", i, err)
+		}
+		if decoded.Entry == nil {
+			continue
+		}
+		entries[decoded.LastModifiedLedger] = append(entries[decoded.LastModifiedLedger], *decoded.Entry)
+	}
+	return entries, nil
+}
+
+// AlignToKeys returns the responses in the order of requestedKeys: the
+// returned slice is parallel to requestedKeys and holds, for each key, the
+// response carrying its entry, or nil if no response does, e.g. when the entry
+// is dead. Responses whose entry was not requested are reported as an error.
+func AlignToKeys(responses []GetLedgerEntryResponse, requestedKeys []xdr.LedgerKey) ([]*GetLedgerEntryResponse, error) {
+	indexes := make(map[string]int, len(requestedKeys))
+	for i, key := range requestedKeys {
+		encoded, err := key.MarshalBinaryBase64()
+		if err != nil {
+			return nil, fmt.Errorf("could not encode requested key %d: %w", i, err)
+		}
+		indexes[encoded] = i
+	}
+
+	aligned := make([]*GetLedgerEntryResponse, len(requestedKeys))
+	for i := range responses {
+		decoded, err := responses[i].ToDecoded()
+		if err != nil {
+			return nil, fmt.Errorf("response %d: %w", i, err)
+		}
+		if decoded.Key == nil {
+			continue
+		}
+		encoded, err := decoded.Key.MarshalBinaryBase64()
+		if err != nil {
+			return nil, fmt.Errorf("response %d: could not encode ledger key: %w", i, err)
+		}
+		index, ok := indexes[encoded]
+		if !ok {
+			return nil, fmt.Errorf("response %d: ledger key %s was not requested", i, encoded)
+		}
+		aligned[index] = &responses[i]
+	}
+	return aligned, nil
+}
+
+// EntriesOfType decodes the entries of the given responses whose type is t,
+// keeping the order of the responses. The type of each entry is read from its
+// encoded header, so entries of other types are not decoded and cannot fail
+// the call unless their header is invalid. Responses for dead entries are
+// skipped.
+func EntriesOfType(responses []GetLedgerEntryResponse, t xdr.LedgerEntryType) ([]xdr.LedgerEntry, error) {
+	var entries []xdr.LedgerEntry
+	for i, response := range responses {
+		if response.Entry != "" {
+			entryType, err := encodedEntryType(response.Entry)
+			if err != nil {
+				return nil, fmt.Errorf("response %d: %w", i, err)
+			}
+			if entryType != t {
+				continue
+			}
+		}
+		decoded, err := response.ToDecoded()
+		if err != nil {
+			return nil, fmt.Errorf("response %d: %w", i, err)
+		}
+		if decoded.Entry == nil {
+			continue
+		}
+		entries = append(entries, *decoded.Entry)
+	}
+	return entries, nil
+}
+
+// encodedEntryType returns the type of the base64 XDR ledger entry without
+// decoding it: the type is the discriminant of the entry data, which follows
+// the 4 bytes of the last modified ledger sequence.
+func encodedEntryType(encoded string) (xdr.LedgerEntryType, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, fmt.Errorf("could not decode ledger entry: %w", err)
+	}
+	if len(raw) < 8 {
+		return 0, fmt.Errorf("could not decode ledger entry: %d bytes are too short", len(raw))
+	}
+	return xdr.LedgerEntryType(int32(binary.BigEndian.Uint32(raw[4:8]))), nil
+}
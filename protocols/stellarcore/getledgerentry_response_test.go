@@ -0,0 +1,257 @@
+package stellarcore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/xdr"
+)
+
+const testAccount = "GC3C4AKRBQLHOJ45U4XG35ESVWRDECWO5XLDGYADO6DPR3L7KIDVUMML"
+
+func TestGetLedgerEntryResponseToDecoded(t *testing.T) {
+	for _, entry := range []xdr.LedgerEntry{
+		{
+			LastModifiedLedgerSeq: 10,
+			Data: xdr.LedgerEntryData{
+				Type: xdr.LedgerEntryTypeAccount,
+				Account: &xdr.AccountEntry{
+					AccountId: xdr.MustAddress(testAccount),
+					Balance:   100,
+				},
+			},
+		},
+		{
+			LastModifiedLedgerSeq: 20,
+			Data: xdr.LedgerEntryData{
+				Type: xdr.LedgerEntryTypeTrustline,
+				TrustLine: &xdr.TrustLineEntry{
+					AccountId: xdr.MustAddress(testAccount),
+					Asset:     xdr.MustNewCreditAsset("USD", testAccount).ToTrustLineAsset(),
+					Limit:     1000,
+				},
+			},
+		},
+	} {
+		t.Run(entry.Data.Type.String(), func(t *testing.T) {
+			encoded, err := xdr.MarshalBase64(entry)
+			require.NoError(t, err)
+			expectedKey, err := entry.LedgerKey()
+			require.NoError(t, err)
+
+			decoded, err := GetLedgerEntryResponse{State: LiveState, Entry: encoded, Ledger: 30}.ToDecoded()
+			require.NoError(t, err)
+			require.Equal(t, LedgerEntryLive, decoded.State)
+			require.Equal(t, entry.Data.Type, decoded.Type)
+			require.Equal(t, &entry, decoded.Entry)
+			require.Equal(t, &expectedKey, decoded.Key)
+			require.Equal(t, uint32(entry.LastModifiedLedgerSeq), decoded.LastModifiedLedger)
+			require.Equal(t, int64(30), decoded.Ledger)
+		})
+	}
+}
+
+func TestGetLedgerEntryResponseToDecodedDead(t *testing.T) {
+	decoded, err := GetLedgerEntryResponse{State: DeadState, Ledger: 30}.ToDecoded()
+	require.NoError(t, err)
+	require.Equal(t, DecodedLedgerEntryResponse{State: LedgerEntryDead, Ledger: 30}, decoded)
+	require.Equal(t, DeadState, decoded.State.String())
+}
+
+func TestGetLedgerEntryResponseToDecodedErrors(t *testing.T) {
+	_, err := GetLedgerEntryResponse{State: "archived"}.ToDecoded()
+	require.EqualError(t, err, `unknown ledger entry state "archived"`)
+
+	_, err = GetLedgerEntryResponse{State: LiveState}.ToDecoded()
+	require.EqualError(t, err, "live ledger entry response is missing the entry")
+
+	_, err = GetLedgerEntryResponse{State: LiveState, Entry: "not xdr"}.ToDecoded()
+	require.ErrorContains(t, err, "could not decode ledger entry")
+}
+
+func TestGetLedgerEntryResponseValidate(t *testing.T) {
+	require.NoError(t, GetLedgerEntryResponse{State: LiveState, Entry: "AAAA", Ledger: 30}.Validate())
+	require.NoError(t, GetLedgerEntryResponse{State: DeadState, Ledger: 30}.Validate())
+
+	err := GetLedgerEntryResponse{State: "archived", Ledger: 30}.Validate()
+	require.EqualError(t, err, `unknown ledger entry state "archived"`)
+
+	err = GetLedgerEntryResponse{State: LiveState, Ledger: 30}.Validate()
+	require.EqualError(t, err, "live ledger entry response is missing the entry")
+
+	err = GetLedgerEntryResponse{State: DeadState}.Validate()
+	require.EqualError(t, err, "invalid ledger 0 in ledger entry response")
+	err = GetLedgerEntryResponse{State: LiveState, Entry: "AAAA", Ledger: -1}.Validate()
+	require.EqualError(t, err, "invalid ledger -1 in ledger entry response")
+}
+
+func TestEntriesByLastModified(t *testing.T) {
+	accountEntry := func(balance xdr.Int64, lastModified xdr.Uint32) (xdr.LedgerEntry, GetLedgerEntryResponse) {
+		entry := xdr.LedgerEntry{
+			LastModifiedLedgerSeq: lastModified,
+			Data: xdr.LedgerEntryData{
+				Type:    xdr.LedgerEntryTypeAccount,
+				Account: &xdr.AccountEntry{AccountId: xdr.MustAddress(testAccount), Balance: balance},
+			},
+		}
+		encoded, err := xdr.MarshalBase64(entry)
+		require.NoError(t, err)
+		return entry, GetLedgerEntryResponse{State: LiveState, Entry: encoded, Ledger: 40}
+	}
+	first, firstResponse := accountEntry(1, 10)
+	second, secondResponse := accountEntry(2, 20)
+	third, thirdResponse := accountEntry(3, 10)
+
+	entries, err := EntriesByLastModified([]GetLedgerEntryResponse{
+		firstResponse,
+		secondResponse,
+		{State: DeadState, Ledger: 40},
+		thirdResponse,
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[uint32][]xdr.LedgerEntry{
+		10: {first, third},
+		20: {second},
+	}, entries)
+
+	_, err = EntriesByLastModified([]GetLedgerEntryResponse{firstResponse, {State: "archived"}})
+	require.EqualError(t, err, `response 1: unknown ledger entry state "archived"`)
+}
+
+func TestAlignToKeys(t *testing.T) {
+	accountResponse := func(address string) (xdr.LedgerKey, GetLedgerEntryResponse) {
+		entry := xdr.LedgerEntry{
+			LastModifiedLedgerSeq: 10,
+			Data: xdr.LedgerEntryData{
+				Type:    xdr.LedgerEntryTypeAccount,
+				Account: &xdr.AccountEntry{AccountId: xdr.MustAddress(address)},
+			},
+		}
+		key, err := entry.LedgerKey()
+		require.NoError(t, err)
+		encoded, err := xdr.MarshalBase64(entry)
+		require.NoError(t, err)
+		return key, GetLedgerEntryResponse{State: LiveState, Entry: encoded, Ledger: 40}
+	}
+	firstKey, firstResponse := accountResponse(testAccount)
+	secondKey, secondResponse := accountResponse("GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H")
+	missingKey, _ := accountResponse("GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL")
+
+	// core returned the entries in another order and omitted the dead one
+	responses := []GetLedgerEntryResponse{secondResponse, {State: DeadState, Ledger: 40}, firstResponse}
+	aligned, err := AlignToKeys(responses, []xdr.LedgerKey{firstKey, missingKey, secondKey})
+	require.NoError(t, err)
+	require.Equal(t, []*GetLedgerEntryResponse{&responses[2], nil, &responses[0]}, aligned)
+
+	aligned, err = AlignToKeys(nil, []xdr.LedgerKey{firstKey})
+	require.NoError(t, err)
+	require.Equal(t, []*GetLedgerEntryResponse{nil}, aligned)
+
+	_, err = AlignToKeys(responses, []xdr.LedgerKey{firstKey})
+	require.ErrorContains(t, err, "response 0: ledger key")
+	require.ErrorContains(t, err, "was not requested")
+}
+
+func TestEntriesOfType(t *testing.T) {
+	response := func(entry xdr.LedgerEntry) GetLedgerEntryResponse {
+		encoded, err := xdr.MarshalBase64(entry)
+		require.NoError(t, err)
+		return GetLedgerEntryResponse{State: LiveState, Entry: encoded, Ledger: 40}
+	}
+	account := xdr.LedgerEntry{
+		LastModifiedLedgerSeq: 10,
+		Data: xdr.LedgerEntryData{
+			Type:    xdr.LedgerEntryTypeAccount,
+			Account: &xdr.AccountEntry{AccountId: xdr.MustAddress(testAccount), Balance: 100},
+		},
+	}
+	otherAccount := xdr.LedgerEntry{
+		LastModifiedLedgerSeq: 20,
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeAccount,
+			Account: &xdr.AccountEntry{
+				AccountId: xdr.MustAddress("GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H"),
+			},
+		},
+	}
+	trustline := xdr.LedgerEntry{
+		LastModifiedLedgerSeq: 20,
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeTrustline,
+			TrustLine: &xdr.TrustLineEntry{
+				AccountId: xdr.MustAddress(testAccount),
+				Asset:     xdr.MustNewCreditAsset("USD", testAccount).ToTrustLineAsset(),
+				Limit:     1000,
+			},
+		},
+	}
+	mixed := []GetLedgerEntryResponse{
+		response(account),
+		response(trustline),
+		{State: DeadState, Ledger: 40},
+		response(otherAccount),
+	}
+	// a trustline header followed by an invalid body
+	corruptTrustline := GetLedgerEntryResponse{State: LiveState, Entry: "AAAACgAAAAH/////", Ledger: 40}
+
+	for _, testCase := range []struct {
+		name        string
+		responses   []GetLedgerEntryResponse
+		entryType   xdr.LedgerEntryType
+		expected    []xdr.LedgerEntry
+		expectedErr string
+	}{
+		{
+			name:      "accounts",
+			responses: mixed,
+			entryType: xdr.LedgerEntryTypeAccount,
+			expected:  []xdr.LedgerEntry{account, otherAccount},
+		},
+		{
+			name:      "trustlines",
+			responses: mixed,
+			entryType: xdr.LedgerEntryTypeTrustline,
+			expected:  []xdr.LedgerEntry{trustline},
+		},
+		{
+			name:      "no match",
+			responses: mixed,
+			entryType: xdr.LedgerEntryTypeContractData,
+		},
+		{
+			name:      "corrupt entry of another type",
+			responses: append([]GetLedgerEntryResponse{corruptTrustline}, mixed...),
+			entryType: xdr.LedgerEntryTypeAccount,
+			expected:  []xdr.LedgerEntry{account, otherAccount},
+		},
+		{
+			name:        "corrupt entry of the requested type",
+			responses:   append([]GetLedgerEntryResponse{corruptTrustline}, mixed...),
+			entryType:   xdr.LedgerEntryTypeTrustline,
+			expectedErr: "response 0: could not decode ledger entry",
+		},
+		{
+			name:        "invalid header",
+			responses:   []GetLedgerEntryResponse{{State: LiveState, Entry: "AAAA", Ledger: 40}},
+			entryType:   xdr.LedgerEntryTypeAccount,
+			expectedErr: "response 0: could not decode ledger entry: 3 bytes are too short",
+		},
+		{
+			name:        "unknown state",
+			responses:   []GetLedgerEntryResponse{response(account), {State: "archived"}},
+			entryType:   xdr.LedgerEntryTypeAccount,
+			expectedErr: `response 1: unknown ledger entry state "archived"`,
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			entries, err := EntriesOfType(testCase.responses, testCase.entryType)
+			if testCase.expectedErr != "" {
+				require.ErrorContains(t, err, testCase.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, testCase.expected, entries)
+		})
+	}
+}
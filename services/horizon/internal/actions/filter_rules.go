@@ -2,6 +2,7 @@ package actions
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -12,7 +13,11 @@ import (
 )
 
 // these admin HTTP endpoints are documented in services/horizon/internal/httpx/static/admin_oapi.yml
-type FilterConfigHandler struct{}
+type FilterConfigHandler struct {
+	// MaxWhitelistSize is the maximum number of entries accepted in a filter
+	// whitelist. A value of 0 disables the limit.
+	MaxWhitelistSize uint
+}
 
 func (handler FilterConfigHandler) GetAssetConfig(w http.ResponseWriter, r *http.Request) {
 	historyQ, err := horizonContext.HistoryQFromRequest(r)
@@ -68,6 +73,10 @@ func (handler FilterConfigHandler) UpdateAccountConfig(w http.ResponseWriter, r
 		problem.Render(r.Context(), w, err)
 		return
 	}
+	if err = handler.checkWhitelistSize("account", filterRequest.Whitelist); err != nil {
+		problem.Render(r.Context(), w, err)
+		return
+	}
 
 	filterConfig := history.AccountFilterConfig{}
 	filterConfig.Enabled = *filterRequest.Enabled
@@ -76,6 +85,7 @@ func (handler FilterConfigHandler) UpdateAccountConfig(w http.ResponseWriter, r
 	config, err := historyQ.UpdateAccountFilterConfig(r.Context(), filterConfig)
 	if err != nil {
 		problem.Render(r.Context(), w, err)
+		return
 	}
 
 	responsePayload := handler.accountConfigResource(config)
@@ -97,6 +107,10 @@ func (handler FilterConfigHandler) UpdateAssetConfig(w http.ResponseWriter, r *h
 		problem.Render(r.Context(), w, err)
 		return
 	}
+	if err = handler.checkWhitelistSize("asset", filterRequest.Whitelist); err != nil {
+		problem.Render(r.Context(), w, err)
+		return
+	}
 
 	filterConfig := history.AssetFilterConfig{}
 	filterConfig.Enabled = *filterRequest.Enabled
@@ -105,6 +119,7 @@ func (handler FilterConfigHandler) UpdateAssetConfig(w http.ResponseWriter, r *h
 	config, err := historyQ.UpdateAssetFilterConfig(r.Context(), filterConfig)
 	if err != nil {
 		problem.Render(r.Context(), w, err)
+		return
 	}
 
 	responsePayload := handler.assetConfigResource(config)
@@ -118,8 +133,7 @@ func (handler FilterConfigHandler) assetFilterResource(r *http.Request) (hProtoc
 	var filterRequest hProtocol.AssetFilterConfig
 	dec := json.NewDecoder(r.Body)
 	if err := dec.Decode(&filterRequest); err != nil {
-		p := problem.NewProblemWithInvalidField(problem.BadRequest, "reason", fmt.Errorf("invalid json for asset filter config %v", err.Error()))
-		return hProtocol.AssetFilterConfig{}, p
+		return hProtocol.AssetFilterConfig{}, filterConfigProblem("asset", err)
 	}
 	return filterRequest, nil
 }
@@ -128,12 +142,35 @@ func (handler FilterConfigHandler) accountFilterResource(r *http.Request) (hProt
 	var filterRequest hProtocol.AccountFilterConfig
 	dec := json.NewDecoder(r.Body)
 	if err := dec.Decode(&filterRequest); err != nil {
-		p := problem.NewProblemWithInvalidField(problem.BadRequest, "reason", fmt.Errorf("invalid json for account filter config %v", err.Error()))
-		return hProtocol.AccountFilterConfig{}, p
+		return hProtocol.AccountFilterConfig{}, filterConfigProblem("account", err)
 	}
 	return filterRequest, nil
 }
 
+// checkWhitelistSize rejects whitelists with more entries than MaxWhitelistSize.
+func (handler FilterConfigHandler) checkWhitelistSize(filterType string, whitelist []string) error {
+	if handler.MaxWhitelistSize == 0 || uint(len(whitelist)) <= handler.MaxWhitelistSize {
+		return nil
+	}
+	return problem.NewProblemWithInvalidField(
+		problem.BadRequest,
+		"whitelist",
+		fmt.Errorf("%s filter whitelist has %d entries, exceeding the maximum of %d",
+			filterType, len(whitelist), handler.MaxWhitelistSize),
+	)
+}
+
+// filterConfigProblem builds a bad request problem for an invalid filter config
+// request, listing the offending fields when the config failed validation.
+func filterConfigProblem(filterType string, err error) *problem.P {
+	p := problem.NewProblemWithInvalidField(problem.BadRequest, "reason", fmt.Errorf("invalid json for %s filter config %v", filterType, err.Error()))
+	var validationErr *hProtocol.FilterValidationError
+	if errors.As(err, &validationErr) {
+		p.Extras["invalid_fields"] = validationErr.Fields
+	}
+	return p
+}
+
 func (handler FilterConfigHandler) assetConfigResource(config history.AssetFilterConfig) hProtocol.AssetFilterConfig {
 	return hProtocol.AssetFilterConfig{
 		Whitelist:    config.Whitelist,
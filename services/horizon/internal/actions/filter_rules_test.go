@@ -1,7 +1,10 @@
 package actions
 
 import (
+	"database/sql/driver"
 	"encoding/json"
+	"errors"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -11,6 +14,11 @@ import (
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/go/services/horizon/internal/db2/history"
 	"github.com/stellar/go/services/horizon/internal/test"
+	"github.com/stellar/go/support/db"
+	"github.com/stellar/go/support/render/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetAssetFilterConfig(t *testing.T) {
@@ -244,3 +252,70 @@ func TestUpdateAccountFilterConfig(t *testing.T) {
 	tt.Assert.True(filterCfgResource.LastModified > 0)
 	tt.Assert.ElementsMatch(filterCfgResource.Whitelist, []string{"4", "5", "6"})
 }
+
+func TestUpdateFilterConfigWhitelistSizeLimit(t *testing.T) {
+	tt := test.Start(t)
+	defer tt.Finish()
+	test.ResetHorizonDB(t, tt.HorizonDB)
+
+	q := &history.Q{SessionInterface: tt.HorizonSession()}
+	handler := &FilterConfigHandler{MaxWhitelistSize: 3}
+
+	for _, testCase := range []struct {
+		whitelist string
+		status    int
+	}{
+		{`["4","5"]`, http.StatusOK},
+		{`["4","5","6"]`, http.StatusOK},
+		{`["4","5","6","7"]`, http.StatusBadRequest},
+	} {
+		for _, update := range []http.HandlerFunc{handler.UpdateAssetConfig, handler.UpdateAccountConfig} {
+			recorder := httptest.NewRecorder()
+			request := makeRequest(
+				t,
+				map[string]string{},
+				map[string]string{},
+				q,
+			)
+			request.Body = ioutil.NopCloser(strings.NewReader(
+				`{"whitelist": ` + testCase.whitelist + `, "enabled": true}`))
+
+			update(recorder, request)
+			tt.Assert.Equal(testCase.status, recorder.Result().StatusCode, testCase.whitelist)
+		}
+	}
+
+	config, err := q.GetAssetFilterConfig(tt.Ctx)
+	tt.Assert.NoError(err)
+	tt.Assert.ElementsMatch([]string{"4", "5", "6"}, []string(config.Whitelist))
+}
+
+func TestUpdateFilterConfigErrorWritesSingleResponse(t *testing.T) {
+	for _, filterType := range []string{"asset", "account"} {
+		t.Run(filterType, func(t *testing.T) {
+			session := &db.MockSession{}
+			session.On("Exec", mock.Anything, mock.Anything).
+				Return(driver.RowsAffected(0), errors.New("connection refused")).Once()
+
+			handler := &FilterConfigHandler{}
+			recorder := httptest.NewRecorder()
+			request := makeRequest(t, map[string]string{}, map[string]string{}, session)
+			request.Body = ioutil.NopCloser(strings.NewReader(`{"whitelist": [], "enabled": true}`))
+			if filterType == "asset" {
+				handler.UpdateAssetConfig(recorder, request)
+			} else {
+				handler.UpdateAccountConfig(recorder, request)
+			}
+
+			resp := recorder.Result()
+			assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+			// the error is the only payload, no filter config follows it
+			dec := json.NewDecoder(resp.Body)
+			var p problem.P
+			require.NoError(t, dec.Decode(&p))
+			assert.Equal(t, http.StatusInternalServerError, p.Status)
+			assert.ErrorIs(t, dec.Decode(&json.RawMessage{}), io.EOF)
+			session.AssertExpectations(t)
+		})
+	}
+}
@@ -522,6 +522,7 @@ func (a *App) init() error {
 		NetworkPassphrase:       a.config.NetworkPassphrase,
 		MaxPathLength:           a.config.MaxPathLength,
 		MaxAssetsPerPathRequest: a.config.MaxAssetsPerPathRequest,
+		MaxFilterWhitelistSize:  a.config.MaxFilterWhitelistSize,
 		PathFinder:              a.paths,
 		PrometheusRegistry:      a.prometheusRegistry,
 		CoreGetter:              a,
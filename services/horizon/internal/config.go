@@ -18,6 +18,9 @@ type Config struct {
 	HistoryArchiveURLs []string
 	Port               uint
 	AdminPort          uint
+	// MaxFilterWhitelistSize is the maximum number of entries accepted by the
+	// admin API in an ingestion filter whitelist. A value of 0 disables the limit.
+	MaxFilterWhitelistSize uint
 
 	CaptiveCoreBinaryPath       string
 	CaptiveCoreConfigPath       string
@@ -125,4 +128,7 @@ type Config struct {
 	DisableTxSub bool
 	// SkipTxmeta, when enabled, will not store meta xdr in history transaction table
 	SkipTxmeta bool
+	// IngestFilterSeedFile is the path of a file seeding the ingestion filters
+	// which were not configured through the admin API yet.
+	IngestFilterSeedFile string
 }
@@ -3,9 +3,14 @@ package history
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/lib/pq"
+
+	"github.com/stellar/go/support/collections/set"
 )
 
 const (
@@ -28,11 +33,83 @@ type AccountFilterConfig struct {
 	LastModified int64          `db:"last_modified"`
 }
 
+// FilterConfig is implemented by the configs of every ingestion filter.
+type FilterConfig interface {
+	// Summary returns a single line describing the filter config.
+	Summary() string
+}
+
+// Summary returns a single line describing the account filter config, e.g.
+// "account filter: enabled, 2 accounts, last modified 2024-01-02T15:04:05Z".
+func (config AccountFilterConfig) Summary() string {
+	return summarizeFilter("account", config.Enabled, len(config.Whitelist), config.LastModified)
+}
+
+// Summary returns a single line describing the asset filter config, e.g.
+// "asset filter: disabled, 1 asset, never modified".
+func (config AssetFilterConfig) Summary() string {
+	return summarizeFilter("asset", config.Enabled, len(config.Whitelist), config.LastModified)
+}
+
+// SummarizeFilters returns the summaries of configs, one per line, in the given order.
+func SummarizeFilters(configs []FilterConfig) string {
+	lines := make([]string, len(configs))
+	for i, config := range configs {
+		lines[i] = config.Summary()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func summarizeFilter(kind string, enabled bool, count int, lastModified int64) string {
+	state := "disabled"
+	if enabled {
+		state = "enabled"
+	}
+	noun := kind
+	if count != 1 {
+		noun += "s"
+	}
+	modified := "never modified"
+	if lastModified > 0 {
+		modified = "last modified " + time.Unix(lastModified, 0).UTC().Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%s filter: %s, %d %s, %s", kind, state, count, noun, modified)
+}
+
+// Equal reports whether both configs enable the same whitelist, regardless of the
+// order of the entries. LastModified is ignored.
+func (config AccountFilterConfig) Equal(other AccountFilterConfig) bool {
+	return config.Enabled == other.Enabled && sameEntries(config.Whitelist, other.Whitelist)
+}
+
+// Equal reports whether both configs enable the same whitelist, regardless of the
+// order of the entries. LastModified is ignored.
+func (config AssetFilterConfig) Equal(other AssetFilterConfig) bool {
+	return config.Enabled == other.Enabled && sameEntries(config.Whitelist, other.Whitelist)
+}
+
+func sameEntries(a, b []string) bool {
+	entries := set.NewSet[string](len(a))
+	for _, entry := range a {
+		entries.Add(entry)
+	}
+	others := set.NewSet[string](len(b))
+	for _, entry := range b {
+		if !entries.Contains(entry) {
+			return false
+		}
+		others.Add(entry)
+	}
+	return len(entries) == len(others)
+}
+
 type QFilter interface {
 	GetAccountFilterConfig(ctx context.Context) (AccountFilterConfig, error)
 	GetAssetFilterConfig(ctx context.Context) (AssetFilterConfig, error)
 	UpdateAssetFilterConfig(ctx context.Context, config AssetFilterConfig) (AssetFilterConfig, error)
 	UpdateAccountFilterConfig(ctx context.Context, config AccountFilterConfig) (AccountFilterConfig, error)
+	IsAccountFilterModified(ctx context.Context, knownLastModified int64) (bool, error)
+	IsAssetFilterModified(ctx context.Context, knownLastModified int64) (bool, error)
 }
 
 func (q *Q) GetAccountFilterConfig(ctx context.Context) (AccountFilterConfig, error) {
@@ -51,6 +128,27 @@ func (q *Q) GetAssetFilterConfig(ctx context.Context) (AssetFilterConfig, error)
 	return filterConfig, err
 }
 
+// IsAccountFilterModified reports whether the account filter config was modified
+// since knownLastModified, without loading the whitelist.
+func (q *Q) IsAccountFilterModified(ctx context.Context, knownLastModified int64) (bool, error) {
+	return q.isFilterModified(ctx, accountFilterRulesTableName, knownLastModified)
+}
+
+// IsAssetFilterModified reports whether the asset filter config was modified
+// since knownLastModified, without loading the whitelist.
+func (q *Q) IsAssetFilterModified(ctx context.Context, knownLastModified int64) (bool, error) {
+	return q.isFilterModified(ctx, assetFilterRulesTableName, knownLastModified)
+}
+
+func (q *Q) isFilterModified(ctx context.Context, tableName string, knownLastModified int64) (bool, error) {
+	var lastModified int64
+	sql := sq.Select(lastModifiedColumnName).From(tableName)
+	if err := q.Get(ctx, &lastModified, sql); err != nil {
+		return false, err
+	}
+	return lastModified != knownLastModified, nil
+}
+
 func (q *Q) UpdateAssetFilterConfig(ctx context.Context, config AssetFilterConfig) (AssetFilterConfig, error) {
 	updateCols := map[string]interface{}{
 		lastModifiedColumnName: sq.Expr(`extract(epoch from now() at time zone 'utc')`),
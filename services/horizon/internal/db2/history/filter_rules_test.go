@@ -1,8 +1,11 @@
 package history
 
 import (
+	"database/sql"
 	"testing"
 
+	sq "github.com/Masterminds/squirrel"
+
 	"github.com/stellar/go/services/horizon/internal/test"
 	"github.com/stretchr/testify/assert"
 )
@@ -43,3 +46,85 @@ func TestAccountFilterConfig(t *testing.T) {
 	tt.Assert.Equal(fc1Result.Enabled, true)
 	tt.Assert.ElementsMatch(fc1Result.Whitelist, []string{"1", "2"})
 }
+
+func TestIsFilterModified(t *testing.T) {
+	tt := test.Start(t)
+	defer tt.Finish()
+	test.ResetHorizonDB(t, tt.HorizonDB)
+	q := &Q{tt.HorizonSession()}
+
+	account, err := q.UpdateAccountFilterConfig(tt.Ctx, AccountFilterConfig{Enabled: true, Whitelist: []string{"1"}})
+	tt.Assert.NoError(err)
+	modified, err := q.IsAccountFilterModified(tt.Ctx, account.LastModified)
+	tt.Assert.NoError(err)
+	tt.Assert.False(modified)
+	modified, err = q.IsAccountFilterModified(tt.Ctx, account.LastModified-1)
+	tt.Assert.NoError(err)
+	tt.Assert.True(modified)
+
+	asset, err := q.GetAssetFilterConfig(tt.Ctx)
+	tt.Assert.NoError(err)
+	modified, err = q.IsAssetFilterModified(tt.Ctx, asset.LastModified)
+	tt.Assert.NoError(err)
+	tt.Assert.False(modified)
+	modified, err = q.IsAssetFilterModified(tt.Ctx, asset.LastModified+1)
+	tt.Assert.NoError(err)
+	tt.Assert.True(modified)
+
+	_, err = q.Exec(tt.Ctx, sq.Delete(assetFilterRulesTableName))
+	tt.Assert.NoError(err)
+	_, err = q.IsAssetFilterModified(tt.Ctx, asset.LastModified)
+	tt.Assert.Equal(sql.ErrNoRows, err)
+}
+
+func TestFilterConfigSummary(t *testing.T) {
+	account := AccountFilterConfig{
+		Enabled:      true,
+		Whitelist:    []string{"GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL", "GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H"},
+		LastModified: 1704207845,
+	}
+	assert.Equal(t, "account filter: enabled, 2 accounts, last modified 2024-01-02T15:04:05Z", account.Summary())
+
+	asset := AssetFilterConfig{
+		Enabled:      true,
+		Whitelist:    []string{"USDC:GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL"},
+		LastModified: 1704207845,
+	}
+	assert.Equal(t, "asset filter: enabled, 1 asset, last modified 2024-01-02T15:04:05Z", asset.Summary())
+
+	disabled := AssetFilterConfig{}
+	assert.Equal(t, "asset filter: disabled, 0 assets, never modified", disabled.Summary())
+
+	assert.Equal(t,
+		"account filter: enabled, 2 accounts, last modified 2024-01-02T15:04:05Z\n"+
+			"asset filter: disabled, 0 assets, never modified",
+		SummarizeFilters([]FilterConfig{account, disabled}))
+}
+
+func TestFilterConfigEqual(t *testing.T) {
+	account := AccountFilterConfig{
+		Enabled:      true,
+		Whitelist:    []string{"GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL", "GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H"},
+		LastModified: 1,
+	}
+	reordered := AccountFilterConfig{
+		Enabled:      true,
+		Whitelist:    []string{"GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H", "GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL", "GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H"},
+		LastModified: 2,
+	}
+	assert.True(t, account.Equal(reordered))
+	assert.True(t, reordered.Equal(account))
+
+	disabled := reordered
+	disabled.Enabled = false
+	assert.False(t, account.Equal(disabled))
+
+	subset := AccountFilterConfig{Enabled: true, Whitelist: []string{"GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL"}}
+	assert.False(t, account.Equal(subset))
+	assert.False(t, subset.Equal(account))
+
+	asset := AssetFilterConfig{Enabled: true, Whitelist: []string{"USDC:GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL", "native"}}
+	assert.True(t, asset.Equal(AssetFilterConfig{Enabled: true, Whitelist: []string{"native", "USDC:GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL"}, LastModified: 5}))
+	assert.False(t, asset.Equal(AssetFilterConfig{Enabled: true, Whitelist: []string{"native", "EURC:GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL"}}))
+	assert.True(t, AssetFilterConfig{}.Equal(AssetFilterConfig{Whitelist: []string{}}))
+}
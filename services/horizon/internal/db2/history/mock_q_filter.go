@@ -23,10 +23,20 @@ func (m *MockQFilter) GetAssetFilterConfig(ctx context.Context) (AssetFilterConf
 
 func (m *MockQFilter) UpdateAccountFilterConfig(ctx context.Context, config AccountFilterConfig) (AccountFilterConfig, error) {
 	a := m.Called(ctx, config)
-	return a.Get(0).(AccountFilterConfig), a.Error(0)
+	return a.Get(0).(AccountFilterConfig), a.Error(1)
 }
 
 func (m *MockQFilter) UpdateAssetFilterConfig(ctx context.Context, config AssetFilterConfig) (AssetFilterConfig, error) {
 	a := m.Called(ctx, config)
-	return a.Get(0).(AssetFilterConfig), a.Error(0)
+	return a.Get(0).(AssetFilterConfig), a.Error(1)
+}
+
+func (m *MockQFilter) IsAccountFilterModified(ctx context.Context, knownLastModified int64) (bool, error) {
+	a := m.Called(ctx, knownLastModified)
+	return a.Bool(0), a.Error(1)
+}
+
+func (m *MockQFilter) IsAssetFilterModified(ctx context.Context, knownLastModified int64) (bool, error) {
+	a := m.Called(ctx, knownLastModified)
+	return a.Bool(0), a.Error(1)
 }
@@ -63,6 +63,8 @@ const (
 	DisableTxSubFlagName = "disable-tx-sub"
 	// SkipTxmeta is the command line flag for disabling persistence of tx meta in history transaction table
 	SkipTxmeta = "skip-txmeta"
+	// IngestFilterSeedFileFlagName is the command line flag for the file seeding ingestion filters
+	IngestFilterSeedFileFlagName = "ingest-filter-seed-file"
 
 	// StellarPubnet is a constant representing the Stellar public network
 	StellarPubnet = "pubnet"
@@ -397,6 +399,14 @@ func Flags() (*Config, support.ConfigOptions) {
 			Usage:          "WARNING: this should not be accessible from the Internet and does not use TLS, tcp port to listen on for admin http requests, 0 (default) disables the admin server",
 			UsedInCommands: append(ApiServerCommands, RecordMetricsCmd),
 		},
+		&support.ConfigOption{
+			Name:           "max-filter-whitelist-size",
+			ConfigKey:      &config.MaxFilterWhitelistSize,
+			OptType:        types.Uint,
+			FlagDefault:    uint(0),
+			Usage:          "the maximum number of entries in an ingestion filter whitelist accepted by the admin API, 0 (default) disables the limit",
+			UsedInCommands: ApiServerCommands,
+		},
 		&support.ConfigOption{
 			Name:           "max-db-connections",
 			ConfigKey:      &config.MaxDBConnections,
@@ -825,6 +835,16 @@ func Flags() (*Config, support.ConfigOptions) {
 			Usage:          "excludes tx meta from persistence on transaction history",
 			UsedInCommands: IngestionCommands,
 		},
+		&support.ConfigOption{
+			Name:        IngestFilterSeedFileFlagName,
+			ConfigKey:   &config.IngestFilterSeedFile,
+			OptType:     types.String,
+			FlagDefault: "",
+			Required:    false,
+			Usage: "path of a JSON file seeding the account and asset ingestion filters when ingestion starts. " +
+				"Filters already configured through the admin API are left untouched.",
+			UsedInCommands: IngestionCommands,
+		},
 	}
 
 	return config, flags
@@ -46,6 +46,7 @@ type RouterConfig struct {
 	NetworkPassphrase       string
 	MaxPathLength           uint
 	MaxAssetsPerPathRequest int
+	MaxFilterWhitelistSize  uint
 	PathFinder              paths.Finder
 	PrometheusRegistry      *prometheus.Registry
 	CoreGetter              actions.CoreStateGetter
@@ -398,7 +399,7 @@ func (r *Router) addRoutes(config *RouterConfig, rateLimiter *throttled.HTTPRate
 	r.Internal.Get("/debug/pprof/heap", pprof.Index)
 	r.Internal.Get("/debug/pprof/profile", pprof.Profile)
 	r.Internal.Route("/ingestion/filters", func(r chi.Router) {
-		handler := actions.FilterConfigHandler{}
+		handler := actions.FilterConfigHandler{MaxWhitelistSize: config.MaxFilterWhitelistSize}
 		r.With(historyMiddleware).Put("/asset", handler.UpdateAssetConfig)
 		r.With(historyMiddleware).Put("/account", handler.UpdateAccountConfig)
 		r.With(historyMiddleware).Get("/asset", handler.GetAssetConfig)
@@ -49,19 +49,28 @@ func (f *accountFilter) FilterTransaction(ctx context.Context, transaction inges
 		return false, true, nil
 	}
 
-	participants, err := processors.ParticipantsForTransaction(0, transaction)
+	_, matched, err := f.matchedAccount(transaction)
 	if err != nil {
 		return true, false, err
 	}
+	return true, matched, nil
+}
+
+// matchedAccount returns the first whitelisted account participating in transaction.
+func (f *accountFilter) matchedAccount(transaction ingest.LedgerTransaction) (string, bool, error) {
+	participants, err := processors.ParticipantsForTransaction(0, transaction)
+	if err != nil {
+		return "", false, err
+	}
 
 	// NOTE: this assumes that the participant list has a small memory footprint
 	//       otherwise, we should be doing the filtering on the DB side
 	for _, p := range participants {
-		if f.whitelistedAccountsSet.Contains(p.Address()) {
-			return true, true, nil
+		if address := p.Address(); f.whitelistedAccountsSet.Contains(address) {
+			return address, true, nil
 		}
 	}
-	return true, false, nil
+	return "", false, nil
 }
 
 func (f accountFilter) isEnabled() bool {
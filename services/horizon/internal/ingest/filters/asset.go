@@ -56,6 +56,17 @@ func (f *assetFilter) FilterTransaction(ctx context.Context, transaction ingest.
 		return false, true, nil
 	}
 
+	if _, matched := f.matchedAsset(transaction); matched {
+		return true, true, nil
+	}
+
+	logger.Debugf("No match, dropped tx with seq %v ", transaction.Envelope.SeqNum())
+	return true, false, nil
+}
+
+// matchedAsset returns the canonical form of the first whitelisted asset
+// referenced by the operations of transaction.
+func (f assetFilter) matchedAsset(transaction ingest.LedgerTransaction) (string, bool) {
 	var operations []xdr.Operation
 
 	if txv1, v1Exists := transaction.Envelope.GetV1(); v1Exists {
@@ -66,74 +77,52 @@ func (f *assetFilter) FilterTransaction(ctx context.Context, transaction ingest.
 		operations = txv0.Tx.Operations
 	}
 
-	if f.filterOperationsMatchedOnRules(operations) {
-		return true, true, nil
-	}
-
-	logger.Debugf("No match, dropped tx with seq %v ", transaction.Envelope.SeqNum())
-	return true, false, nil
+	return f.filterOperationsMatchedOnRules(operations)
 }
 
-func (f assetFilter) filterOperationsMatchedOnRules(operations []xdr.Operation) bool {
+func (f assetFilter) filterOperationsMatchedOnRules(operations []xdr.Operation) (string, bool) {
 	for _, operation := range operations {
+		var assets []*xdr.Asset
 		switch operation.Body.Type {
 		case xdr.OperationTypeChangeTrust:
-			if f.filterChangeTrustMatched(operation) {
-				return true
-			}
+			assets = changeTrustAssets(operation)
 		case xdr.OperationTypeManageSellOffer:
-			if f.assetMatchedFilter(&operation.Body.ManageSellOfferOp.Buying) || f.assetMatchedFilter(&operation.Body.ManageSellOfferOp.Selling) {
-				return true
-			}
+			assets = []*xdr.Asset{&operation.Body.ManageSellOfferOp.Buying, &operation.Body.ManageSellOfferOp.Selling}
 		case xdr.OperationTypeManageBuyOffer:
-			if f.assetMatchedFilter(&operation.Body.ManageBuyOfferOp.Buying) || f.assetMatchedFilter(&operation.Body.ManageBuyOfferOp.Selling) {
-				return true
-			}
+			assets = []*xdr.Asset{&operation.Body.ManageBuyOfferOp.Buying, &operation.Body.ManageBuyOfferOp.Selling}
 		case xdr.OperationTypeCreateClaimableBalance:
-			if f.assetMatchedFilter(&operation.Body.CreateClaimableBalanceOp.Asset) {
-				return true
-			}
+			assets = []*xdr.Asset{&operation.Body.CreateClaimableBalanceOp.Asset}
 		case xdr.OperationTypeCreatePassiveSellOffer:
-			if f.assetMatchedFilter(&operation.Body.CreatePassiveSellOfferOp.Buying) || f.assetMatchedFilter(&operation.Body.CreatePassiveSellOfferOp.Selling) {
-				return true
-			}
+			assets = []*xdr.Asset{&operation.Body.CreatePassiveSellOfferOp.Buying, &operation.Body.CreatePassiveSellOfferOp.Selling}
 		case xdr.OperationTypeClawback:
-			if f.assetMatchedFilter(&operation.Body.ClawbackOp.Asset) {
-				return true
-			}
+			assets = []*xdr.Asset{&operation.Body.ClawbackOp.Asset}
 		case xdr.OperationTypePayment:
-			if f.assetMatchedFilter(&operation.Body.PaymentOp.Asset) {
-				return true
-			}
+			assets = []*xdr.Asset{&operation.Body.PaymentOp.Asset}
 		case xdr.OperationTypePathPaymentStrictReceive:
-			if f.assetMatchedFilter(&operation.Body.PathPaymentStrictReceiveOp.DestAsset) || f.assetMatchedFilter(&operation.Body.PathPaymentStrictReceiveOp.SendAsset) {
-				return true
-			}
+			assets = []*xdr.Asset{&operation.Body.PathPaymentStrictReceiveOp.DestAsset, &operation.Body.PathPaymentStrictReceiveOp.SendAsset}
 		case xdr.OperationTypePathPaymentStrictSend:
-			if f.assetMatchedFilter(&operation.Body.PathPaymentStrictSendOp.DestAsset) || f.assetMatchedFilter(&operation.Body.PathPaymentStrictSendOp.SendAsset) {
-				return true
+			assets = []*xdr.Asset{&operation.Body.PathPaymentStrictSendOp.DestAsset, &operation.Body.PathPaymentStrictSendOp.SendAsset}
+		}
+		for _, asset := range assets {
+			if canonical, ok := f.assetMatchedFilter(asset); ok {
+				return canonical, true
 			}
 		}
 	}
-	return false
+	return "", false
 }
 
-func (f assetFilter) filterChangeTrustMatched(operation xdr.Operation) bool {
+func changeTrustAssets(operation xdr.Operation) []*xdr.Asset {
 	if pool, ok := operation.Body.ChangeTrustOp.Line.GetLiquidityPool(); ok {
-		if f.assetMatchedFilter(&pool.ConstantProduct.AssetA) || f.assetMatchedFilter(&pool.ConstantProduct.AssetB) {
-			return true
-		}
-	} else {
-		asset := operation.Body.ChangeTrustOp.Line.ToAsset()
-		if f.assetMatchedFilter(&asset) {
-			return true
-		}
+		return []*xdr.Asset{&pool.ConstantProduct.AssetA, &pool.ConstantProduct.AssetB}
 	}
-	return false
+	asset := operation.Body.ChangeTrustOp.Line.ToAsset()
+	return []*xdr.Asset{&asset}
 }
 
-func (f *assetFilter) assetMatchedFilter(asset *xdr.Asset) bool {
-	return f.canonicalAssetsLookup.Contains(asset.StringCanonical())
+func (f *assetFilter) assetMatchedFilter(asset *xdr.Asset) (string, bool) {
+	canonical := asset.StringCanonical()
+	return canonical, f.canonicalAssetsLookup.Contains(canonical)
 }
 
 func listToSet(list []string) set.Set[string] {
@@ -0,0 +1,141 @@
+package filters
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/services/horizon/internal/ingest/processors"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// FilterMatch names a filter which included a transaction and the whitelist
+// rule responsible for it.
+type FilterMatch struct {
+	Filter string
+	Rule   string
+}
+
+// Explanation describes why a transaction would be ingested or dropped by a
+// set of filters.
+type Explanation struct {
+	// IngestAll is true when none of the filters is enabled, in which case every
+	// transaction is ingested.
+	IngestAll bool
+	// Matches lists the enabled filters which included the transaction.
+	Matches []FilterMatch
+}
+
+// Included reports whether the transaction would be ingested.
+func (e Explanation) Included() bool {
+	return e.IngestAll || len(e.Matches) > 0
+}
+
+func (e Explanation) String() string {
+	if e.IngestAll {
+		return "no rules / ingest-all"
+	}
+	if len(e.Matches) == 0 {
+		return "no rule matched, transaction dropped"
+	}
+	parts := make([]string, len(e.Matches))
+	for i, match := range e.Matches {
+		parts[i] = fmt.Sprintf("%s matched %s", match.Filter, match.Rule)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ruleMatcher is implemented by filters able to report which of their rules
+// matched a transaction.
+type ruleMatcher interface {
+	matchedRule(transaction ingest.LedgerTransaction) (string, bool, error)
+}
+
+func (f *accountFilter) matchedRule(transaction ingest.LedgerTransaction) (string, bool, error) {
+	account, matched, err := f.matchedAccount(transaction)
+	if !matched || err != nil {
+		return "", matched, err
+	}
+	return "account " + account, true, nil
+}
+
+func (f *assetFilter) matchedRule(transaction ingest.LedgerTransaction) (string, bool, error) {
+	asset, matched := f.matchedAsset(transaction)
+	if !matched {
+		return "", false, nil
+	}
+	return "asset " + asset, true, nil
+}
+
+// Explain evaluates transaction against filterers the same way ingestion does
+// and reports every enabled filter which included it, along with the rule that
+// matched. It is intended for debugging unexpected filtering decisions.
+func Explain(ctx context.Context, filterers []processors.LedgerTransactionFilterer, transaction ingest.LedgerTransaction) (Explanation, error) {
+	explanation := Explanation{IngestAll: true}
+	for _, filterer := range filterers {
+		enabled, include, err := filterer.FilterTransaction(ctx, transaction)
+		if err != nil {
+			return Explanation{}, errors.Wrapf(err, "error in %s", filterer.Name())
+		}
+		if !enabled {
+			continue
+		}
+		explanation.IngestAll = false
+		if !include {
+			continue
+		}
+
+		match := FilterMatch{Filter: filterer.Name()}
+		if matcher, ok := filterer.(ruleMatcher); ok {
+			if match.Rule, _, err = matcher.matchedRule(transaction); err != nil {
+				return Explanation{}, errors.Wrapf(err, "error in %s", filterer.Name())
+			}
+		}
+		explanation.Matches = append(explanation.Matches, match)
+	}
+	return explanation, nil
+}
+
+// EvaluateAgainstFilters loads the current filter configs from q and reports
+// whether ingestion would keep txEnvelope, along with the rules which included
+// it. The envelope is evaluated as a successful transaction without meta, so
+// only the accounts and assets appearing in the envelope itself can match.
+func EvaluateAgainstFilters(ctx context.Context, q history.QFilter, txEnvelope xdr.TransactionEnvelope) (bool, []string, error) {
+	assetConfig, err := q.GetAssetFilterConfig(ctx)
+	if err != nil {
+		return false, nil, errors.Wrap(err, "error loading asset filter config")
+	}
+	assetFilter := NewAssetFilter()
+	if err = assetFilter.RefreshAssetFilter(&assetConfig); err != nil {
+		return false, nil, errors.Wrap(err, "error loading asset filter config")
+	}
+	accountConfig, err := q.GetAccountFilterConfig(ctx)
+	if err != nil {
+		return false, nil, errors.Wrap(err, "error loading account filter config")
+	}
+	accountFilter := NewAccountFilter()
+	if err = accountFilter.RefreshAccountFilter(&accountConfig); err != nil {
+		return false, nil, errors.Wrap(err, "error loading account filter config")
+	}
+
+	explanation, err := Explain(ctx, []processors.LedgerTransactionFilterer{assetFilter, accountFilter}, ingest.LedgerTransaction{
+		Envelope: txEnvelope,
+		Result: xdr.TransactionResultPair{
+			Result: xdr.TransactionResult{
+				Result: xdr.TransactionResultResult{Code: xdr.TransactionResultCodeTxSuccess},
+			},
+		},
+		UnsafeMeta: xdr.TransactionMeta{V: 1, V1: &xdr.TransactionMetaV1{}},
+	})
+	if err != nil {
+		return false, nil, err
+	}
+	var matched []string
+	for _, match := range explanation.Matches {
+		matched = append(matched, match.Rule)
+	}
+	return explanation.Included(), matched, nil
+}
@@ -0,0 +1,115 @@
+package filters
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/services/horizon/internal/ingest/processors"
+)
+
+func TestExplainNamesMatchingFilter(t *testing.T) {
+	tt := assert.New(t)
+	ctx := context.Background()
+
+	accountFilter := NewAccountFilter()
+	tt.NoError(accountFilter.RefreshAccountFilter(&history.AccountFilterConfig{
+		Whitelist:    []string{"GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL"},
+		Enabled:      true,
+		LastModified: 1,
+	}))
+	assetFilter := NewAssetFilter()
+	tt.NoError(assetFilter.RefreshAssetFilter(&history.AssetFilterConfig{
+		Whitelist:    []string{"USDC:GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H"},
+		Enabled:      true,
+		LastModified: 1,
+	}))
+	filterers := []processors.LedgerTransactionFilterer{accountFilter, assetFilter}
+
+	explanation, err := Explain(ctx, filterers, getAccountTestTx(t,
+		"GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL",
+		"GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL"))
+	tt.NoError(err)
+	tt.True(explanation.Included())
+	tt.Equal([]FilterMatch{{
+		Filter: "filters.accountFilter",
+		Rule:   "account GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL",
+	}}, explanation.Matches)
+
+	explanation, err = Explain(ctx, filterers, getAccountTestTx(t,
+		"GC3C4AKRBQLHOJ45U4XG35ESVWRDECWO5XLDGYADO6DPR3L7KIDVUMML",
+		"GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H"))
+	tt.NoError(err)
+	tt.True(explanation.Included())
+	tt.Equal([]FilterMatch{{
+		Filter: "filters.assetFilter",
+		Rule:   "asset USDC:GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H",
+	}}, explanation.Matches)
+
+	explanation, err = Explain(ctx, filterers, getAccountTestTx(t,
+		"GC3C4AKRBQLHOJ45U4XG35ESVWRDECWO5XLDGYADO6DPR3L7KIDVUMML",
+		"GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL"))
+	tt.NoError(err)
+	tt.False(explanation.Included())
+	tt.Empty(explanation.Matches)
+	tt.Equal("no rule matched, transaction dropped", explanation.String())
+}
+
+func TestExplainIngestAllWhenNoFilterEnabled(t *testing.T) {
+	tt := assert.New(t)
+
+	filterers := []processors.LedgerTransactionFilterer{NewAccountFilter(), NewAssetFilter()}
+	explanation, err := Explain(context.Background(), filterers, getAssetTestV1Tx(t, "GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL"))
+	tt.NoError(err)
+	tt.True(explanation.IngestAll)
+	tt.True(explanation.Included())
+	tt.Equal("no rules / ingest-all", explanation.String())
+}
+
+func TestEvaluateAgainstFilters(t *testing.T) {
+	ctx := context.Background()
+	q := &history.MockQFilter{}
+	q.On("GetAssetFilterConfig", ctx).Return(history.AssetFilterConfig{
+		Whitelist:    []string{"USDC:GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H"},
+		Enabled:      true,
+		LastModified: 1,
+	}, nil).Twice()
+	q.On("GetAccountFilterConfig", ctx).Return(history.AccountFilterConfig{
+		Whitelist:    []string{"GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL"},
+		Enabled:      true,
+		LastModified: 1,
+	}, nil).Twice()
+
+	ingested, matched, err := EvaluateAgainstFilters(ctx, q, getAccountTestTx(t,
+		"GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL",
+		"GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H").Envelope)
+	assert.NoError(t, err)
+	assert.True(t, ingested)
+	assert.Equal(t, []string{
+		"asset USDC:GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H",
+		"account GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL",
+	}, matched)
+
+	ingested, matched, err = EvaluateAgainstFilters(ctx, q, getAccountTestTx(t,
+		"GC3C4AKRBQLHOJ45U4XG35ESVWRDECWO5XLDGYADO6DPR3L7KIDVUMML",
+		"GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL").Envelope)
+	assert.NoError(t, err)
+	assert.False(t, ingested)
+	assert.Empty(t, matched)
+	q.AssertExpectations(t)
+}
+
+func TestEvaluateAgainstFiltersLoadError(t *testing.T) {
+	ctx := context.Background()
+	q := &history.MockQFilter{}
+	q.On("GetAssetFilterConfig", ctx).Return(history.AssetFilterConfig{}, errors.New("db down")).Once()
+
+	_, _, err := EvaluateAgainstFilters(ctx, q, getAccountTestTx(t,
+		"GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL",
+		"GD6WNNTW664WH7FXC5RUMUTF7P5QSURC2IT36VOQEEGFZ4UWUEQGECAL").Envelope)
+	assert.EqualError(t, err, "error loading asset filter config: db down")
+	q.AssertExpectations(t)
+}
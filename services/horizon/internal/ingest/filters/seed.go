@@ -0,0 +1,139 @@
+package filters
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/support/errors"
+)
+
+// SeedFile is the format of a filter seed file, using the same JSON
+// representation of each filter as the admin API. Filters absent from the file
+// are not seeded. Whitelist entries may reference environment variables as
+// ${NAME}, e.g. "USDC:${USDC_ISSUER}", so that the same file can be used on
+// every network.
+type SeedFile struct {
+	Account *hProtocol.AccountFilterConfig `json:"account"`
+	Asset   *hProtocol.AssetFilterConfig   `json:"asset"`
+}
+
+// SeedResult lists the filters written by a seed and the ones left untouched.
+type SeedResult struct {
+	Inserted []string
+	Skipped  []string
+}
+
+// SeedFromFile reads a SeedFile from path and writes each filter it defines
+// to the DB, unless the filter was already configured at runtime, i.e. it has
+// been modified since it was created. Seeding is therefore idempotent and
+// never overrides configuration changes made through the admin API.
+func SeedFromFile(ctx context.Context, filterQ history.QFilter, path string) (SeedResult, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return SeedResult{}, errors.Wrap(err, "error reading filter seed file")
+	}
+	// filters are decoded, and so validated, only once their variables are
+	// expanded
+	var raw struct {
+		Account json.RawMessage `json:"account"`
+		Asset   json.RawMessage `json:"asset"`
+	}
+	if err = json.Unmarshal(contents, &raw); err != nil {
+		return SeedResult{}, errors.Wrapf(err, "error decoding filter seed file %s", path)
+	}
+	var seed SeedFile
+	if raw.Account != nil {
+		seed.Account = &hProtocol.AccountFilterConfig{}
+		if err = decodeSeedFilter(raw.Account, seed.Account, "account", path); err != nil {
+			return SeedResult{}, err
+		}
+	}
+	if raw.Asset != nil {
+		seed.Asset = &hProtocol.AssetFilterConfig{}
+		if err = decodeSeedFilter(raw.Asset, seed.Asset, "asset", path); err != nil {
+			return SeedResult{}, err
+		}
+	}
+
+	var result SeedResult
+	if seed.Account != nil {
+		current, err := filterQ.GetAccountFilterConfig(ctx)
+		if err != nil {
+			return result, errors.Wrap(err, "error loading account filter config")
+		}
+		if current.LastModified != 0 {
+			result.Skipped = append(result.Skipped, "account")
+		} else {
+			config := history.AccountFilterConfig{Enabled: *seed.Account.Enabled, Whitelist: seed.Account.Whitelist}
+			if _, err = filterQ.UpdateAccountFilterConfig(ctx, config); err != nil {
+				return result, errors.Wrap(err, "error seeding account filter config")
+			}
+			result.Inserted = append(result.Inserted, "account")
+		}
+	}
+	if seed.Asset != nil {
+		current, err := filterQ.GetAssetFilterConfig(ctx)
+		if err != nil {
+			return result, errors.Wrap(err, "error loading asset filter config")
+		}
+		if current.LastModified != 0 {
+			result.Skipped = append(result.Skipped, "asset")
+		} else {
+			config := history.AssetFilterConfig{Enabled: *seed.Asset.Enabled, Whitelist: seed.Asset.Whitelist}
+			if _, err = filterQ.UpdateAssetFilterConfig(ctx, config); err != nil {
+				return result, errors.Wrap(err, "error seeding asset filter config")
+			}
+			result.Inserted = append(result.Inserted, "asset")
+		}
+	}
+	return result, nil
+}
+
+// decodeSeedFilter expands the variables referenced in the whitelist of the
+// filter config in data, then decodes the result into config, which validates
+// it.
+func decodeSeedFilter(data json.RawMessage, config json.Unmarshaler, filterType, path string) error {
+	var filter struct {
+		Whitelist []string `json:"whitelist"`
+		Enabled   *bool    `json:"enabled"`
+	}
+	if err := json.Unmarshal(data, &filter); err != nil {
+		return errors.Wrapf(err, "error decoding filter seed file %s", path)
+	}
+	if err := expandVariables(filter.Whitelist); err != nil {
+		return errors.Wrapf(err, "error resolving %s filter in seed file %s", filterType, path)
+	}
+	expanded, err := json.Marshal(filter)
+	if err != nil {
+		return errors.Wrapf(err, "error encoding %s filter in seed file %s", filterType, path)
+	}
+	if err = config.UnmarshalJSON(expanded); err != nil {
+		return errors.Wrapf(err, "error decoding filter seed file %s", path)
+	}
+	return nil
+}
+
+// expandVariables replaces the variables referenced in whitelist entries with
+// the value of the environment variable of the same name. Variables which are
+// not set are reported as an error instead of being replaced with an empty
+// string.
+func expandVariables(whitelist []string) error {
+	var unresolved []string
+	for i, entry := range whitelist {
+		whitelist[i] = os.Expand(entry, func(name string) string {
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				unresolved = append(unresolved, name)
+			}
+			return value
+		})
+	}
+	if len(unresolved) > 0 {
+		return errors.Errorf("unresolved variables: %s", strings.Join(unresolved, ", "))
+	}
+	return nil
+}
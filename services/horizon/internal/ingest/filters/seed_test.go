@@ -0,0 +1,111 @@
+package filters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+)
+
+func writeSeedFile(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "filters.json")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+func TestSeedFromFile(t *testing.T) {
+	ctx := context.Background()
+	path := writeSeedFile(t, `{
+		"account": {"enabled": true, "whitelist": ["GC3C4AKRBQLHOJ45U4XG35ESVWRDECWO5XLDGYADO6DPR3L7KIDVUMML"]},
+		"asset": {"enabled": true, "whitelist": ["USDC:GC3C4AKRBQLHOJ45U4XG35ESVWRDECWO5XLDGYADO6DPR3L7KIDVUMML"]}
+	}`)
+
+	q := &history.MockQFilter{}
+	// the account filter was configured at runtime, the asset filter never was
+	q.On("GetAccountFilterConfig", ctx).Return(history.AccountFilterConfig{
+		Enabled:      false,
+		Whitelist:    pq.StringArray{"GDBZOMUZF4GNQNUQNKOJOGB4HNZAHUBHLT5TKVOJSGW4GKVCQ2CFLNGU"},
+		LastModified: 1704207845,
+	}, nil).Once()
+	q.On("GetAssetFilterConfig", ctx).Return(history.AssetFilterConfig{}, nil).Once()
+	expectedAsset := history.AssetFilterConfig{
+		Enabled:   true,
+		Whitelist: pq.StringArray{"USDC:GC3C4AKRBQLHOJ45U4XG35ESVWRDECWO5XLDGYADO6DPR3L7KIDVUMML"},
+	}
+	q.On("UpdateAssetFilterConfig", ctx, expectedAsset).Return(expectedAsset, nil).Once()
+
+	result, err := SeedFromFile(ctx, q, path)
+	require.NoError(t, err)
+	assert.Equal(t, SeedResult{Inserted: []string{"asset"}, Skipped: []string{"account"}}, result)
+	q.AssertExpectations(t)
+}
+
+func TestSeedFromFilePartial(t *testing.T) {
+	ctx := context.Background()
+	path := writeSeedFile(t, `{"account": {"enabled": false, "whitelist": []}}`)
+
+	q := &history.MockQFilter{}
+	q.On("GetAccountFilterConfig", ctx).Return(history.AccountFilterConfig{}, nil).Once()
+	expected := history.AccountFilterConfig{Enabled: false, Whitelist: pq.StringArray{}}
+	q.On("UpdateAccountFilterConfig", ctx, expected).Return(expected, nil).Once()
+
+	result, err := SeedFromFile(ctx, q, path)
+	require.NoError(t, err)
+	assert.Equal(t, SeedResult{Inserted: []string{"account"}}, result)
+	q.AssertExpectations(t)
+}
+
+func TestSeedFromFileInvalid(t *testing.T) {
+	q := &history.MockQFilter{}
+	_, err := SeedFromFile(context.Background(), q, writeSeedFile(t, `{"asset": {"whitelist": ["USDC"]}}`))
+	assert.ErrorContains(t, err, "error decoding filter seed file")
+
+	_, err = SeedFromFile(context.Background(), q, filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorContains(t, err, "error reading filter seed file")
+	q.AssertExpectations(t)
+}
+
+func TestSeedFromFileVariables(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("USDC_ISSUER", "GC3C4AKRBQLHOJ45U4XG35ESVWRDECWO5XLDGYADO6DPR3L7KIDVUMML")
+	path := writeSeedFile(t, `{"asset": {"enabled": true, "whitelist": ["USDC:${USDC_ISSUER}"]}}`)
+
+	q := &history.MockQFilter{}
+	q.On("GetAssetFilterConfig", ctx).Return(history.AssetFilterConfig{}, nil).Once()
+	expected := history.AssetFilterConfig{
+		Enabled:   true,
+		Whitelist: pq.StringArray{"USDC:GC3C4AKRBQLHOJ45U4XG35ESVWRDECWO5XLDGYADO6DPR3L7KIDVUMML"},
+	}
+	q.On("UpdateAssetFilterConfig", ctx, expected).Return(expected, nil).Once()
+
+	result, err := SeedFromFile(ctx, q, path)
+	require.NoError(t, err)
+	assert.Equal(t, SeedResult{Inserted: []string{"asset"}}, result)
+	q.AssertExpectations(t)
+}
+
+func TestSeedFromFileUnresolvedVariable(t *testing.T) {
+	path := writeSeedFile(t, `{"account": {"enabled": true, "whitelist": ["${SEED_TEST_UNSET_ACCOUNT}"]}}`)
+
+	q := &history.MockQFilter{}
+	_, err := SeedFromFile(context.Background(), q, path)
+	assert.EqualError(t, err, "error resolving account filter in seed file "+path+
+		": unresolved variables: SEED_TEST_UNSET_ACCOUNT")
+	q.AssertExpectations(t)
+}
+
+func TestSeedFromFileValidatesExpandedVariables(t *testing.T) {
+	t.Setenv("SEED_TEST_ISSUER", "GC3C4AKRBQLHOJ45U4XG35ESVWRDECWO5XLDGYADO6DPR3L7KIDVUMML")
+	path := writeSeedFile(t, `{"account": {"whitelist": ["${SEED_TEST_ISSUER}"]}}`)
+
+	q := &history.MockQFilter{}
+	_, err := SeedFromFile(context.Background(), q, path)
+	assert.EqualError(t, err, "error decoding filter seed file "+path+": missing required enabled")
+	q.AssertExpectations(t)
+}
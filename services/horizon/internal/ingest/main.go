@@ -133,6 +133,9 @@ type Config struct {
 
 	MaxLedgerPerFlush uint32
 	SkipTxmeta        bool
+	// FilterSeedFile, if set, is the path of a filters.SeedFile applied when
+	// ingestion starts.
+	FilterSeedFile string
 
 	CoreProtocolVersionFn ledgerbackend.CoreProtocolVersionFunc
 	CoreBuildVersionFn    ledgerbackend.CoreBuildVersionFunc
@@ -570,9 +573,28 @@ func (s *system) RegisterMetrics(registry *prometheus.Registry) {
 //   - If instances is a NOT leader, it runs ledger pipeline without updating a
 //     a database so order book graph is updated but database is not overwritten.
 func (s *system) Run() {
+	if err := s.seedFilters(); err != nil {
+		log.WithError(err).Error("Error seeding ingestion filters")
+	}
 	s.runStateMachine(startState{})
 }
 
+// seedFilters seeds the ingestion filters from config.FilterSeedFile, if set.
+func (s *system) seedFilters() error {
+	if s.config.FilterSeedFile == "" {
+		return nil
+	}
+	result, err := filters.SeedFromFile(s.ctx, s.historyQ, s.config.FilterSeedFile)
+	if err != nil {
+		return err
+	}
+	log.WithFields(logpkg.F{
+		"inserted": result.Inserted,
+		"skipped":  result.Skipped,
+	}).Info("Seeded ingestion filters")
+	return nil
+}
+
 func (s *system) StressTest(numTransactions, changesPerTransaction int) error {
 	if numTransactions <= 0 {
 		return errors.New("transactions must be positive")
@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -402,6 +405,25 @@ func assertErrorRestartMetrics(reg *prometheus.Registry, assertCurrentState stri
 	}
 }
 
+func TestSeedFilters(t *testing.T) {
+	ctx := context.Background()
+	historyQ := &mockDBQ{}
+	system := &system{historyQ: historyQ, ctx: ctx}
+	// seeding is disabled unless a seed file is configured
+	assert.NoError(t, system.seedFilters())
+
+	system.config.FilterSeedFile = filepath.Join(t.TempDir(), "filters.json")
+	assert.ErrorContains(t, system.seedFilters(), "error reading filter seed file")
+
+	assert.NoError(t, os.WriteFile(system.config.FilterSeedFile,
+		[]byte(`{"asset": {"enabled": true, "whitelist": ["USDC:`+issuer.Address()+`"]}}`), 0o600))
+	historyQ.MockQFilter.On("GetAssetFilterConfig", ctx).Return(history.AssetFilterConfig{}, nil).Once()
+	expected := history.AssetFilterConfig{Enabled: true, Whitelist: pq.StringArray{"USDC:" + issuer.Address()}}
+	historyQ.MockQFilter.On("UpdateAssetFilterConfig", ctx, expected).Return(expected, nil).Once()
+	assert.NoError(t, system.seedFilters())
+	historyQ.MockQFilter.AssertExpectations(t)
+}
+
 type mockDBQ struct {
 	mock.Mock
 
@@ -103,6 +103,7 @@ func initIngester(app *App) {
 		EnableExtendedLogLedgerStats:         app.config.IngestEnableExtendedLogLedgerStats,
 		RoundingSlippageFilter:               app.config.RoundingSlippageFilter,
 		SkipTxmeta:                           app.config.SkipTxmeta,
+		FilterSeedFile:                       app.config.IngestFilterSeedFile,
 		ReapConfig: ingest.ReapConfig{
 			Frequency:      app.config.ReapFrequency,
 			RetentionCount: uint32(app.config.HistoryRetentionCount),
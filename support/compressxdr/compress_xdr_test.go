@@ -46,3 +46,40 @@ func TestEncodeDecodeLedgerCloseMetaBatch(t *testing.T) {
 		require.Equal(t, testData.LedgerCloseMetas[i], decodedData.LedgerCloseMetas[i])
 	}
 }
+
+func TestZstdCompressionLevels(t *testing.T) {
+	_, err := NewZstdCompressor(ZstdBestCompression + 1)
+	require.EqualError(t, err, "invalid zstd compression level 23, must be between 1 and 22")
+	_, err = NewZstdCompressor(-1)
+	require.Error(t, err)
+
+	testData := xdr.LedgerCloseMetaBatch{StartSequence: 1000, EndSequence: 1999}
+	for i := uint32(1000); i < 2000; i++ {
+		testData.LedgerCloseMetas = append(testData.LedgerCloseMetas, xdr.LedgerCloseMeta{
+			V: 0,
+			V0: &xdr.LedgerCloseMetaV0{
+				LedgerHeader: xdr.LedgerHeaderHistoryEntry{
+					Header: xdr.LedgerHeader{LedgerSeq: xdr.Uint32(i), BaseFee: 100, BaseReserve: 5000000},
+				},
+			},
+		})
+	}
+
+	sizes := map[int]int{}
+	for _, level := range []int{ZstdBestSpeed, ZstdBestCompression} {
+		compressor, err := NewZstdCompressor(level)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		_, err = NewXDREncoder(compressor, testData).WriteTo(&buf)
+		require.NoError(t, err)
+		sizes[level] = buf.Len()
+
+		// objects written at any level are readable with the default compressor
+		var decoded xdr.LedgerCloseMetaBatch
+		_, err = NewXDRDecoder(DefaultCompressor, &decoded).ReadFrom(&buf)
+		require.NoError(t, err)
+		require.Equal(t, testData, decoded)
+	}
+	require.Less(t, sizes[ZstdBestCompression], sizes[ZstdBestSpeed])
+}
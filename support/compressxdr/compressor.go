@@ -1,6 +1,7 @@
 package compressxdr
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/klauspost/compress/zstd"
@@ -15,8 +16,29 @@ type Compressor interface {
 	Name() string
 }
 
+const (
+	// ZstdBestSpeed is the zstd compression level favouring speed over size.
+	ZstdBestSpeed = 1
+	// ZstdBestCompression is the zstd compression level favouring size over speed.
+	ZstdBestCompression = 22
+)
+
 // ZstdCompressor is an implementation of the Compressor interface for Zstd compression.
-type ZstdCompressor struct{}
+type ZstdCompressor struct {
+	// Level is the zstd compression level used by NewWriter, 0 selects the
+	// default level.
+	Level int
+}
+
+// NewZstdCompressor returns a ZstdCompressor writing at the given level, which
+// must be 0 (the default level) or between ZstdBestSpeed and ZstdBestCompression.
+func NewZstdCompressor(level int) (*ZstdCompressor, error) {
+	if level != 0 && (level < ZstdBestSpeed || level > ZstdBestCompression) {
+		return nil, fmt.Errorf("invalid zstd compression level %d, must be between %d and %d",
+			level, ZstdBestSpeed, ZstdBestCompression)
+	}
+	return &ZstdCompressor{Level: level}, nil
+}
 
 // GetName returns the name of the compression algorithm.
 func (z ZstdCompressor) Name() string {
@@ -25,6 +47,9 @@ func (z ZstdCompressor) Name() string {
 
 // NewWriter creates a new Zstd writer.
 func (z ZstdCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	if z.Level != 0 {
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(z.Level)))
+	}
 	return zstd.NewWriter(w)
 }
 
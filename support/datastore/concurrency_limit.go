@@ -0,0 +1,56 @@
+package datastore
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// WithConcurrencyLimit decorates the given DataStore so that at most limit
+// GetFile calls are in flight at any time. A call is in flight from the moment
+// it is made until the returned reader is closed. Callers waiting for a slot
+// are served in the order they arrived, so that a worker cannot be starved by
+// others repeatedly taking the freed slots, and give up when their context is
+// done. A limit <= 0 disables the limit and returns dataStore unchanged.
+func WithConcurrencyLimit(dataStore DataStore, limit int) DataStore {
+	if limit <= 0 {
+		return dataStore
+	}
+	return concurrencyLimitedDataStore{
+		DataStore: dataStore,
+		slots:     semaphore.NewWeighted(int64(limit)),
+	}
+}
+
+type concurrencyLimitedDataStore struct {
+	DataStore
+	slots *semaphore.Weighted
+}
+
+func (c concurrencyLimitedDataStore) GetFile(ctx context.Context, path string) (io.ReadCloser, error) {
+	if err := c.slots.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	reader, err := c.DataStore.GetFile(ctx, path)
+	if err != nil {
+		c.slots.Release(1)
+		return nil, err
+	}
+	return &slotReleasingReader{ReadCloser: reader, release: func() { c.slots.Release(1) }}, nil
+}
+
+// slotReleasingReader gives back its concurrency slot the first time it is closed.
+type slotReleasingReader struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (r *slotReleasingReader) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
@@ -0,0 +1,113 @@
+package datastore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countingDataStore records the highest number of concurrent GetFile calls,
+// counting each call until its reader is closed.
+type countingDataStore struct {
+	MockDataStore
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (c *countingDataStore) GetFile(ctx context.Context, path string) (io.ReadCloser, error) {
+	current := c.inFlight.Add(1)
+	for {
+		max := c.maxInFlight.Load()
+		if current <= max || c.maxInFlight.CompareAndSwap(max, current) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+	return &countingReader{Reader: bytes.NewReader([]byte(path)), inFlight: &c.inFlight}, nil
+}
+
+type countingReader struct {
+	io.Reader
+	inFlight *atomic.Int32
+}
+
+func (r *countingReader) Close() error {
+	r.inFlight.Add(-1)
+	return nil
+}
+
+func TestConcurrencyLimitIsNeverExceeded(t *testing.T) {
+	counting := &countingDataStore{}
+	store := WithConcurrencyLimit(counting, 3)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reader, err := store.GetFile(context.Background(), "file.txt")
+			require.NoError(t, err)
+			_, err = io.ReadAll(reader)
+			require.NoError(t, err)
+			require.NoError(t, reader.Close())
+		}()
+	}
+	wg.Wait()
+
+	require.LessOrEqual(t, counting.maxInFlight.Load(), int32(3))
+	require.Equal(t, int32(0), counting.inFlight.Load())
+}
+
+func TestConcurrencyLimitHonorsContext(t *testing.T) {
+	store := WithConcurrencyLimit(&countingDataStore{}, 1)
+
+	reader, err := store.GetFile(context.Background(), "file.txt")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = store.GetFile(ctx, "file.txt")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// closing twice releases the slot only once
+	require.NoError(t, reader.Close())
+	require.NoError(t, reader.Close())
+	reader, err = store.GetFile(context.Background(), "file.txt")
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+}
+
+func TestConcurrencyLimitIsFair(t *testing.T) {
+	store := WithConcurrencyLimit(&countingDataStore{}, 1)
+	reader, err := store.GetFile(context.Background(), "file.txt")
+	require.NoError(t, err)
+
+	// queue up waiters one at a time, each one blocked before the next arrives
+	order := make(chan int, 5)
+	for i := 0; i < 5; i++ {
+		go func(i int) {
+			waiting, getErr := store.GetFile(context.Background(), "file.txt")
+			require.NoError(t, getErr)
+			order <- i
+			require.NoError(t, waiting.Close())
+		}(i)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	require.NoError(t, reader.Close())
+	for i := 0; i < 5; i++ {
+		require.Equal(t, i, <-order)
+	}
+}
+
+func TestConcurrencyLimitDisabled(t *testing.T) {
+	counting := &countingDataStore{}
+	require.Same(t, counting, WithConcurrencyLimit(counting, 0))
+	require.Same(t, counting, WithConcurrencyLimit(counting, -1))
+}
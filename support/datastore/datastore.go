@@ -3,6 +3,10 @@ package datastore
 import (
 	"context"
 	"io"
+	"net/http"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/api/option"
 
 	"github.com/stellar/go/support/errors"
 )
@@ -11,6 +15,16 @@ type DataStoreConfig struct {
 	Type   string            `toml:"type"`
 	Params map[string]string `toml:"params"`
 	Schema DataStoreSchema   `toml:"schema"`
+	// MaxConcurrentReads caps the number of GetFile calls in flight across all
+	// users of the DataStore. A value of 0 disables the limit.
+	MaxConcurrentReads int `toml:"max_concurrent_reads"`
+	// Transport, if set, is passed through to the underlying store client and
+	// used for all its requests, e.g. to sign requests or inject headers. The
+	// store's default credentials are not applied to requests sent through a
+	// custom Transport, so it is responsible for authentication.
+	Transport http.RoundTripper `toml:"-"`
+	// Tracer, if set, traces the calls to the DataStore, see WithTracing.
+	Tracer trace.Tracer `toml:"-"`
 }
 
 // DataStore defines an interface for interacting with data storage
@@ -27,14 +41,33 @@ type DataStore interface {
 
 // NewDataStore factory, it creates a new DataStore based on the config type
 func NewDataStore(ctx context.Context, datastoreConfig DataStoreConfig) (DataStore, error) {
+	var dataStore DataStore
+	var err error
 	switch datastoreConfig.Type {
 	case "GCS":
 		destinationBucketPath, ok := datastoreConfig.Params["destination_bucket_path"]
 		if !ok {
 			return nil, errors.Errorf("Invalid GCS config, no destination_bucket_path")
 		}
-		return NewGCSDataStore(ctx, destinationBucketPath, datastoreConfig.Schema)
+		var opts []option.ClientOption
+		if datastoreConfig.Transport != nil {
+			opts = append(opts, option.WithHTTPClient(&http.Client{Transport: datastoreConfig.Transport}))
+		}
+		dataStore, err = newGCSDataStore(ctx, destinationBucketPath, datastoreConfig.Params["user_project"], datastoreConfig.Schema, opts...)
+	case "Tar":
+		archivePath, ok := datastoreConfig.Params["archive_path"]
+		if !ok {
+			return nil, errors.Errorf("Invalid Tar config, no archive_path")
+		}
+		dataStore, err = NewTarDataStore(ctx, archivePath, datastoreConfig.Params["prefix"], datastoreConfig.Schema)
 	default:
 		return nil, errors.Errorf("Invalid datastore type %v, not supported", datastoreConfig.Type)
 	}
+	if err != nil {
+		return nil, err
+	}
+	if datastoreConfig.Tracer != nil {
+		dataStore = WithTracing(dataStore, datastoreConfig.Tracer)
+	}
+	return WithConcurrencyLimit(dataStore, datastoreConfig.MaxConcurrentReads), nil
 }
@@ -2,8 +2,14 @@ package datastore
 
 import (
 	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
 	"testing"
 
+	"github.com/fsouza/fake-gcs-server/fakestorage"
 	"github.com/stretchr/testify/require"
 )
 
@@ -11,3 +17,133 @@ func TestInvalidStore(t *testing.T) {
 	_, err := NewDataStore(context.Background(), DataStoreConfig{Type: "unknown"})
 	require.Error(t, err)
 }
+
+// redirectingTransport sends every request to a fake server, counting them.
+type redirectingTransport struct {
+	target   *url.URL
+	next     http.RoundTripper
+	requests atomic.Int32
+}
+
+func (r *redirectingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.requests.Add(1)
+	req = req.Clone(req.Context())
+	req.URL.Scheme = r.target.Scheme
+	req.URL.Host = r.target.Host
+	req.Host = r.target.Host
+	req.Header.Set("X-Custom-Auth", "signed")
+	return r.next.RoundTrip(req)
+}
+
+func TestNewDataStoreCustomTransport(t *testing.T) {
+	server := fakestorage.NewServer([]fakestorage.Object{
+		{
+			ObjectAttrs: fakestorage.ObjectAttrs{
+				BucketName: "test-bucket",
+				Name:       "objects/testnet/file.txt",
+			},
+			Content: []byte("inside the file"),
+		},
+	})
+	defer server.Stop()
+	target, err := url.Parse(server.URL())
+	require.NoError(t, err)
+	transport := &redirectingTransport{target: target, next: server.HTTPClient().Transport}
+
+	store, err := NewDataStore(context.Background(), DataStoreConfig{
+		Type:      "GCS",
+		Params:    map[string]string{"destination_bucket_path": "test-bucket/objects/testnet"},
+		Transport: transport,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, store.Close())
+	})
+	requests := transport.requests.Load()
+	require.Greater(t, requests, int32(0))
+
+	exists, err := store.Exists(context.Background(), "file.txt")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Greater(t, transport.requests.Load(), requests)
+}
+
+// userProjectTransport records the billing project of every request.
+type userProjectTransport struct {
+	next         http.RoundTripper
+	userProjects []string
+}
+
+func (u *userProjectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	userProject := req.URL.Query().Get("userProject")
+	if userProject == "" {
+		// reads through the XML API carry the billing project in a header
+		userProject = req.Header.Get("X-Goog-User-Project")
+	}
+	u.userProjects = append(u.userProjects, userProject)
+	return u.next.RoundTrip(req)
+}
+
+func TestNewDataStoreUserProject(t *testing.T) {
+	server := fakestorage.NewServer([]fakestorage.Object{
+		{
+			ObjectAttrs: fakestorage.ObjectAttrs{
+				BucketName: "test-bucket",
+				Name:       "objects/testnet/file.txt",
+			},
+			Content: []byte("inside the file"),
+		},
+	})
+	defer server.Stop()
+	target, err := url.Parse(server.URL())
+	require.NoError(t, err)
+	transport := &userProjectTransport{
+		next: &redirectingTransport{target: target, next: server.HTTPClient().Transport},
+	}
+
+	store, err := NewDataStore(context.Background(), DataStoreConfig{
+		Type: "GCS",
+		Params: map[string]string{
+			"destination_bucket_path": "test-bucket/objects/testnet",
+			"user_project":            "billing-project",
+		},
+		Transport: transport,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, store.Close())
+	})
+
+	exists, err := store.Exists(context.Background(), "file.txt")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.NotEmpty(t, transport.userProjects)
+	for _, userProject := range transport.userProjects {
+		require.Equal(t, "billing-project", userProject)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestNewDataStoreRequesterPaysWithoutUserProject(t *testing.T) {
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusBadRequest,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body: io.NopCloser(strings.NewReader(`{"error": {"code": 400, ` +
+				`"message": "Bucket is a requester pays bucket but no user project provided."}}`)),
+			Request: req,
+		}, nil
+	})
+
+	_, err := NewDataStore(context.Background(), DataStoreConfig{
+		Type:      "GCS",
+		Params:    map[string]string{"destination_bucket_path": "test-bucket/objects/testnet"},
+		Transport: transport,
+	})
+	require.ErrorContains(t, err, "bucket test-bucket is requester pays, set user_project to the project to bill")
+}
@@ -14,6 +14,7 @@ import (
 
 	"cloud.google.com/go/storage"
 	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
 
 	"github.com/stellar/go/support/log"
 	"github.com/stellar/go/support/url"
@@ -27,16 +28,27 @@ type GCSDataStore struct {
 	schema DataStoreSchema
 }
 
-func NewGCSDataStore(ctx context.Context, bucketPath string, schema DataStoreSchema) (DataStore, error) {
-	client, err := storage.NewClient(ctx)
+func NewGCSDataStore(ctx context.Context, bucketPath string, schema DataStoreSchema, opts ...option.ClientOption) (DataStore, error) {
+	return newGCSDataStore(ctx, bucketPath, "", schema, opts...)
+}
+
+// newGCSDataStore creates a GCSDataStore billing requests to userProject, which
+// is required to access requester pays buckets. An empty userProject bills the
+// bucket owner.
+func newGCSDataStore(ctx context.Context, bucketPath, userProject string, schema DataStoreSchema, opts ...option.ClientOption) (DataStore, error) {
+	client, err := storage.NewClient(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return FromGCSClient(ctx, client, bucketPath, schema)
+	return fromGCSClient(ctx, client, bucketPath, userProject, schema)
 }
 
 func FromGCSClient(ctx context.Context, client *storage.Client, bucketPath string, schema DataStoreSchema) (DataStore, error) {
+	return fromGCSClient(ctx, client, bucketPath, "", schema)
+}
+
+func fromGCSClient(ctx context.Context, client *storage.Client, bucketPath, userProject string, schema DataStoreSchema) (DataStore, error) {
 	// append the gcs:// scheme to enable usage of the url package reliably to
 	// get parse bucket name which is first path segment as URL.Host
 	gcsBucketURL := fmt.Sprintf("gcs://%s", bucketPath)
@@ -52,12 +64,31 @@ func FromGCSClient(ctx context.Context, client *storage.Client, bucketPath strin
 	log.Infof("creating GCS client for bucket: %s, prefix: %s", bucketName, prefix)
 	// Check the bucket exists
 	bucket := client.Bucket(bucketName)
+	if userProject != "" {
+		bucket = bucket.UserProject(userProject)
+	}
 	if _, err := bucket.Attrs(ctx); err != nil {
+		if userProject == "" && isRequesterPaysError(err) {
+			return nil, fmt.Errorf("bucket %s is requester pays, set user_project to the project to bill: %w", bucketName, err)
+		}
 		return nil, fmt.Errorf("failed to retrieve bucket attributes: %w", err)
 	}
 
-	// TODO: Datastore schema to be fetched from the datastore https://stellarorg.atlassian.net/browse/HUBBLE-397
-	return &GCSDataStore{client: client, bucket: bucket, prefix: prefix, schema: schema}, nil
+	store := &GCSDataStore{client: client, bucket: bucket, prefix: prefix, schema: schema}
+	if store.schema, err = resolveSchema(ctx, store, schema); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// isRequesterPaysError returns true if err was returned by GCS because the
+// bucket is requester pays and the request did not name a project to bill.
+func isRequesterPaysError(err error) bool {
+	var gcsError *googleapi.Error
+	return errors.As(err, &gcsError) &&
+		gcsError.Code == http.StatusBadRequest &&
+		strings.Contains(strings.ToLower(gcsError.Message), "requester pays")
 }
 
 // GetFileMetadata retrieves the metadata for the specified file in the GCS bucket.
@@ -68,6 +99,7 @@ func (b GCSDataStore) GetFileMetadata(ctx context.Context, filePath string) (map
 		if errors.Is(err, storage.ErrObjectNotExist) {
 			return nil, os.ErrNotExist
 		}
+		return nil, fmt.Errorf("error retrieving metadata of file %s: %w", filePath, err)
 	}
 	return attrs.Metadata, nil
 }
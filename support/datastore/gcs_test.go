@@ -5,11 +5,15 @@ import (
 	"compress/gzip"
 	"context"
 	"io"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
 
+	"cloud.google.com/go/storage"
 	"github.com/fsouza/fake-gcs-server/fakestorage"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
 )
 
 func TestGCSExists(t *testing.T) {
@@ -337,6 +341,38 @@ func TestGCSGetNonExistentFile(t *testing.T) {
 	require.Equal(t, map[string]string(nil), metadata)
 }
 
+func TestGCSGetFileMetadataError(t *testing.T) {
+	// the bucket exists but reading object attributes fails
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		status, body := http.StatusForbidden, `{"error": {"code": 403, "message": "access denied"}}`
+		switch {
+		case req.URL.Path == "/storage/v1/b/test-bucket":
+			status, body = http.StatusOK, `{"name": "test-bucket"}`
+		case strings.HasSuffix(req.URL.Path, ManifestFileName):
+			status, body = http.StatusNotFound, ""
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+	client, err := storage.NewClient(context.Background(), option.WithHTTPClient(&http.Client{Transport: transport}))
+	require.NoError(t, err)
+
+	store, err := FromGCSClient(context.Background(), client, "test-bucket/objects/testnet", DataStoreSchema{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, store.Close())
+	})
+
+	metadata, err := store.GetFileMetadata(context.Background(), "file.txt")
+	require.ErrorContains(t, err, "error retrieving metadata of file objects/testnet/file.txt")
+	require.NotErrorIs(t, err, os.ErrNotExist)
+	require.Nil(t, metadata)
+}
+
 func TestGCSGetFileValidatesCRC32C(t *testing.T) {
 	// test on a gzipped file so we can verify ReadCompressed()
 	// was called correctly
@@ -378,6 +414,71 @@ func TestGCSGetFileValidatesCRC32C(t *testing.T) {
 	require.EqualError(t, err, "storage: bad CRC on read: got 985946173, want 2601510353")
 }
 
+func TestGCSSchemaFromManifest(t *testing.T) {
+	server := fakestorage.NewServer([]fakestorage.Object{
+		{
+			ObjectAttrs: fakestorage.ObjectAttrs{
+				BucketName: "test-bucket",
+				Name:       "objects/testnet/.config.json",
+			},
+			Content: []byte(`{"layoutVersion":1,"ledgersPerFile":64,"filesPerPartition":10,"compression":"zstd"}`),
+		},
+	})
+	defer server.Stop()
+
+	// the manifest only applies to stores without a configured schema
+	store, err := FromGCSClient(context.Background(), server.Client(), "test-bucket/objects/testnet", DataStoreSchema{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, store.Close())
+	})
+	require.Equal(t, DataStoreSchema{LedgersPerFile: 64, FilesPerPartition: 10}, store.GetSchema())
+
+	matching := DataStoreSchema{LedgersPerFile: 64, FilesPerPartition: 10}
+	matchingStore, err := FromGCSClient(context.Background(), server.Client(), "test-bucket/objects/testnet", matching)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, matchingStore.Close())
+	})
+	require.Equal(t, matching, matchingStore.GetSchema())
+
+	configured := DataStoreSchema{LedgersPerFile: 1, FilesPerPartition: 64000}
+	_, err = FromGCSClient(context.Background(), server.Client(), "test-bucket/objects/testnet", configured)
+	require.EqualError(t, err, "configured schema {LedgersPerFile:1 FilesPerPartition:64000} conflicts "+
+		"with the datastore manifest schema {LedgersPerFile:64 FilesPerPartition:10}")
+}
+
+func TestGCSSchemaWithoutManifest(t *testing.T) {
+	server := fakestorage.NewServer([]fakestorage.Object{})
+	defer server.Stop()
+	server.CreateBucketWithOpts(fakestorage.CreateBucketOpts{Name: "test-bucket"})
+
+	configured := DataStoreSchema{LedgersPerFile: 1, FilesPerPartition: 64000}
+	store, err := FromGCSClient(context.Background(), server.Client(), "test-bucket/objects/testnet", configured)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, store.Close())
+	})
+
+	require.Equal(t, configured, store.GetSchema())
+}
+
+func TestGCSManifestUnsupportedLayoutVersion(t *testing.T) {
+	server := fakestorage.NewServer([]fakestorage.Object{
+		{
+			ObjectAttrs: fakestorage.ObjectAttrs{
+				BucketName: "test-bucket",
+				Name:       "objects/testnet/.config.json",
+			},
+			Content: []byte(`{"layoutVersion":2,"ledgersPerFile":64,"filesPerPartition":10,"compression":"zstd"}`),
+		},
+	})
+	defer server.Stop()
+
+	_, err := FromGCSClient(context.Background(), server.Client(), "test-bucket/objects/testnet", DataStoreSchema{})
+	require.EqualError(t, err, "invalid manifest: unsupported datastore layout version 2, expected 1")
+}
+
 func requireReaderContentEquals(t *testing.T, reader io.ReadCloser, expected []byte) {
 	var buf bytes.Buffer
 	_, err := io.Copy(&buf, reader)
@@ -23,15 +23,25 @@ func (ec DataStoreSchema) GetSequenceNumberEndBoundary(ledgerSeq uint32) uint32
 	return ec.GetSequenceNumberStartBoundary(ledgerSeq) + ec.LedgersPerFile - 1
 }
 
+// PartitionForSequence returns the first and last ledgers of the partition
+// containing ledgerSeq. When filesPerPartition <= 1 objects are not
+// partitioned, and the boundaries are those of the object containing
+// ledgerSeq.
+func PartitionForSequence(ledgerSeq, ledgersPerFile, filesPerPartition uint32) (start, end uint32) {
+	partitionSize := ledgersPerFile * max(filesPerPartition, 1)
+	if partitionSize == 0 {
+		return 0, 0
+	}
+	start = (ledgerSeq / partitionSize) * partitionSize
+	return start, start + partitionSize - 1
+}
+
 // GetObjectKeyFromSequenceNumber generates the object key name from the ledger sequence number based on configuration.
 func (ec DataStoreSchema) GetObjectKeyFromSequenceNumber(ledgerSeq uint32) string {
 	var objectKey string
 
 	if ec.FilesPerPartition > 1 {
-		partitionSize := ec.LedgersPerFile * ec.FilesPerPartition
-		partitionStart := (ledgerSeq / partitionSize) * partitionSize
-		partitionEnd := partitionStart + partitionSize - 1
-
+		partitionStart, partitionEnd := PartitionForSequence(ledgerSeq, ec.LedgersPerFile, ec.FilesPerPartition)
 		objectKey = fmt.Sprintf("%08X--%d-%d/", math.MaxUint32-partitionStart, partitionStart, partitionEnd)
 	}
 
@@ -38,6 +38,34 @@ func TestGetObjectKeyFromSequenceNumber(t *testing.T) {
 	}
 }
 
+func TestPartitionForSequence(t *testing.T) {
+	testCases := []struct {
+		ledgerSeq         uint32
+		ledgersPerFile    uint32
+		filesPerPartition uint32
+		expectedStart     uint32
+		expectedEnd       uint32
+	}{
+		{0, 64, 10, 0, 639},
+		{639, 64, 10, 0, 639},
+		{640, 64, 10, 640, 1279},
+		{1279, 64, 10, 640, 1279},
+		{63999, 1, 64000, 0, 63999},
+		{64000, 1, 64000, 64000, 127999},
+		// unpartitioned objects are their own partition
+		{5, 1, 0, 5, 5},
+		{5, 10, 1, 0, 9},
+		{10, 10, 1, 10, 19},
+		{5, 0, 10, 0, 0},
+	}
+
+	for _, tc := range testCases {
+		start, end := PartitionForSequence(tc.ledgerSeq, tc.ledgersPerFile, tc.filesPerPartition)
+		require.Equal(t, tc.expectedStart, start, "start of ledger %d", tc.ledgerSeq)
+		require.Equal(t, tc.expectedEnd, end, "end of ledger %d", tc.ledgerSeq)
+	}
+}
+
 func TestGetObjectKeyFromSequenceNumber_ObjectKeyDescOrder(t *testing.T) {
 	config := DataStoreSchema{
 		LedgersPerFile:    1,
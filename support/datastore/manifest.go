@@ -0,0 +1,90 @@
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/stellar/go/support/compressxdr"
+)
+
+const (
+	// ManifestFileName is the object at the root of a datastore which
+	// describes how the exported ledgers are laid out.
+	ManifestFileName = ".config.json"
+
+	// ManifestLayoutVersion is the only layout version understood by this package.
+	ManifestLayoutVersion = 1
+)
+
+// Manifest declares the layout of the ledger files in a datastore, so that
+// readers do not have to be configured to match the exporter which wrote them.
+type Manifest struct {
+	LayoutVersion     uint32 `json:"layoutVersion"`
+	LedgersPerFile    uint32 `json:"ledgersPerFile"`
+	FilesPerPartition uint32 `json:"filesPerPartition"`
+	Compression       string `json:"compression"`
+}
+
+// Schema returns the DataStoreSchema declared by the manifest.
+func (m Manifest) Schema() DataStoreSchema {
+	return DataStoreSchema{
+		LedgersPerFile:    m.LedgersPerFile,
+		FilesPerPartition: m.FilesPerPartition,
+	}
+}
+
+func (m Manifest) validate() error {
+	if m.LayoutVersion != ManifestLayoutVersion {
+		return fmt.Errorf("unsupported datastore layout version %d, expected %d", m.LayoutVersion, ManifestLayoutVersion)
+	}
+	if m.LedgersPerFile == 0 {
+		return errors.New("ledgersPerFile must be > 0")
+	}
+	if m.Compression != compressxdr.DefaultCompressor.Name() {
+		return fmt.Errorf("unsupported compression %q", m.Compression)
+	}
+	return nil
+}
+
+// ReadManifest reads and validates the manifest stored in the datastore.
+// The returned bool is false if the datastore has no manifest.
+func ReadManifest(ctx context.Context, dataStore DataStore) (Manifest, bool, error) {
+	reader, err := dataStore.GetFile(ctx, ManifestFileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return Manifest{}, false, nil
+	} else if err != nil {
+		return Manifest{}, false, fmt.Errorf("error reading manifest: %w", err)
+	}
+	defer reader.Close()
+
+	var manifest Manifest
+	if err = json.NewDecoder(reader).Decode(&manifest); err != nil {
+		return Manifest{}, false, fmt.Errorf("error decoding manifest: %w", err)
+	}
+	if err = manifest.validate(); err != nil {
+		return Manifest{}, false, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return manifest, true, nil
+}
+
+// resolveSchema returns the configured schema, or the schema declared by the
+// manifest of the datastore if no schema was configured. A configured schema
+// which differs from the manifest is an error, since the datastore would be
+// read or written with the wrong layout.
+func resolveSchema(ctx context.Context, dataStore DataStore, schema DataStoreSchema) (DataStoreSchema, error) {
+	manifest, ok, err := ReadManifest(ctx, dataStore)
+	if err != nil || !ok {
+		return schema, err
+	}
+	if schema == (DataStoreSchema{}) {
+		return manifest.Schema(), nil
+	}
+	if schema != manifest.Schema() {
+		return DataStoreSchema{}, fmt.Errorf("configured schema %+v conflicts with the datastore manifest schema %+v",
+			schema, manifest.Schema())
+	}
+	return schema, nil
+}
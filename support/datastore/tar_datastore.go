@@ -0,0 +1,137 @@
+package datastore
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+var errTarReadOnly = errors.New("tar datastore is read-only")
+
+// TarDataStore implements a read-only DataStore over a local tar archive of an
+// export, e.g. one shipped as a single file to an air-gapped host. Objects are
+// read in place without extracting the archive. Tar entries carry no object
+// metadata, so GetFileMetadata always returns an empty map.
+type TarDataStore struct {
+	file    *os.File
+	prefix  string
+	entries map[string]tarEntry
+	schema  DataStoreSchema
+}
+
+// tarEntry locates the contents of a regular file within the archive.
+type tarEntry struct {
+	offset int64
+	size   int64
+}
+
+// NewTarDataStore indexes the tar archive at archivePath. Objects are looked up
+// under prefix within the archive, so that an export packed with its bucket
+// path, e.g. "ledgers/pubnet", can be read with the usual object keys.
+func NewTarDataStore(ctx context.Context, archivePath, prefix string, schema DataStoreSchema) (DataStore, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening archive %s: %w", archivePath, err)
+	}
+
+	entries := map[string]tarEntry{}
+	reader := tar.NewReader(file)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("error reading archive %s: %w", archivePath, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		// tar.Reader does not buffer, so the file is positioned at the
+		// start of the entry contents
+		offset, err := file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("error reading archive %s: %w", archivePath, err)
+		}
+		entries[strings.TrimPrefix(path.Clean(header.Name), "/")] = tarEntry{offset: offset, size: header.Size}
+	}
+
+	store := &TarDataStore{
+		file:    file,
+		prefix:  strings.Trim(path.Clean(prefix), "/"),
+		entries: entries,
+		schema:  schema,
+	}
+	if store.schema, err = resolveSchema(ctx, store, schema); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+func (t TarDataStore) entry(filePath string) (tarEntry, error) {
+	entry, ok := t.entries[path.Join(t.prefix, filePath)]
+	if !ok {
+		return tarEntry{}, os.ErrNotExist
+	}
+	return entry, nil
+}
+
+// GetFileMetadata returns an empty map for files present in the archive.
+func (t TarDataStore) GetFileMetadata(ctx context.Context, filePath string) (map[string]string, error) {
+	if _, err := t.entry(filePath); err != nil {
+		return nil, err
+	}
+	return map[string]string{}, nil
+}
+
+// GetFile returns a reader over a file of the archive.
+func (t TarDataStore) GetFile(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	entry, err := t.entry(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(io.NewSectionReader(t.file, entry.offset, entry.size)), nil
+}
+
+// PutFile always fails, the archive is read-only.
+func (t TarDataStore) PutFile(ctx context.Context, filePath string, in io.WriterTo, metaData map[string]string) error {
+	return fmt.Errorf("error uploading file %s: %w", filePath, errTarReadOnly)
+}
+
+// PutFileIfNotExists always fails, the archive is read-only.
+func (t TarDataStore) PutFileIfNotExists(ctx context.Context, filePath string, in io.WriterTo, metaData map[string]string) (bool, error) {
+	return false, fmt.Errorf("error uploading file %s: %w", filePath, errTarReadOnly)
+}
+
+// Size returns the size of a file in the archive.
+func (t TarDataStore) Size(ctx context.Context, filePath string) (int64, error) {
+	entry, err := t.entry(filePath)
+	if err != nil {
+		return 0, err
+	}
+	return entry.size, nil
+}
+
+// Exists checks if a file exists in the archive.
+func (t TarDataStore) Exists(ctx context.Context, filePath string) (bool, error) {
+	_, err := t.entry(filePath)
+	return err == nil, nil
+}
+
+// GetSchema returns the schema information which defines the structure
+// and organization of data in the datastore.
+func (t TarDataStore) GetSchema() DataStoreSchema {
+	return t.schema
+}
+
+// Close closes the archive.
+func (t TarDataStore) Close() error {
+	return t.file.Close()
+}
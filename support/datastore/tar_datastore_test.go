@@ -0,0 +1,90 @@
+package datastore
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTarArchive(t *testing.T, files map[string]string) string {
+	archivePath := filepath.Join(t.TempDir(), "export.tar")
+	var buf bytes.Buffer
+	writer := tar.NewWriter(&buf)
+	require.NoError(t, writer.WriteHeader(&tar.Header{Name: "./ledgers/", Typeflag: tar.TypeDir, Mode: 0o755}))
+	for name, contents := range files {
+		require.NoError(t, writer.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(contents))}))
+		_, err := writer.Write([]byte(contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	require.NoError(t, os.WriteFile(archivePath, buf.Bytes(), 0o600))
+	return archivePath
+}
+
+func TestTarDataStore(t *testing.T) {
+	ctx := context.Background()
+	archivePath := writeTarArchive(t, map[string]string{
+		"./ledgers/FFFFFFFF--0-639/FFFFFFFF--0-63.xdr.zstd":   "first",
+		"./ledgers/FFFFFFFF--0-639/FFFFFFBF--64-127.xdr.zstd": "second object",
+		"./ledgers/" + ManifestFileName:                       `{"layoutVersion":1,"ledgersPerFile":64,"filesPerPartition":10,"compression":"zstd"}`,
+	})
+
+	store, err := NewDataStore(ctx, DataStoreConfig{
+		Type:   "Tar",
+		Params: map[string]string{"archive_path": archivePath, "prefix": "/ledgers/"},
+	})
+	require.NoError(t, err)
+	// the schema is read from the manifest
+	assert.Equal(t, DataStoreSchema{LedgersPerFile: 64, FilesPerPartition: 10}, store.GetSchema())
+
+	reader, err := store.GetFile(ctx, "FFFFFFFF--0-639/FFFFFFBF--64-127.xdr.zstd")
+	require.NoError(t, err)
+	contents, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "second object", string(contents))
+	require.NoError(t, reader.Close())
+
+	size, err := store.Size(ctx, "FFFFFFFF--0-639/FFFFFFFF--0-63.xdr.zstd")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), size)
+	exists, err := store.Exists(ctx, "FFFFFFFF--0-639/FFFFFFFF--0-63.xdr.zstd")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	metaData, err := store.GetFileMetadata(ctx, "FFFFFFFF--0-639/FFFFFFFF--0-63.xdr.zstd")
+	require.NoError(t, err)
+	assert.Empty(t, metaData)
+
+	// directories and files outside of the prefix are not objects
+	for _, missing := range []string{"FFFFFFFF--0-639/FFFFFF7F--128-191.xdr.zstd", "FFFFFFFF--0-639", "../ledgers"} {
+		_, err = store.GetFile(ctx, missing)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+		exists, err = store.Exists(ctx, missing)
+		require.NoError(t, err)
+		assert.False(t, exists)
+	}
+
+	err = store.PutFile(ctx, "FFFFFFFF--0-639/FFFFFF7F--128-191.xdr.zstd", bytes.NewBufferString("third"), nil)
+	assert.EqualError(t, err, "error uploading file FFFFFFFF--0-639/FFFFFF7F--128-191.xdr.zstd: tar datastore is read-only")
+	require.NoError(t, store.Close())
+}
+
+func TestTarDataStoreErrors(t *testing.T) {
+	ctx := context.Background()
+	_, err := NewDataStore(ctx, DataStoreConfig{Type: "Tar"})
+	assert.EqualError(t, err, "Invalid Tar config, no archive_path")
+
+	_, err = NewTarDataStore(ctx, filepath.Join(t.TempDir(), "missing.tar"), "", DataStoreSchema{})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	archivePath := filepath.Join(t.TempDir(), "invalid.tar")
+	require.NoError(t, os.WriteFile(archivePath, []byte("not a tar archive"), 0o600))
+	_, err = NewTarDataStore(ctx, archivePath, "", DataStoreSchema{})
+	assert.ErrorContains(t, err, "error reading archive")
+}
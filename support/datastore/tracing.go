@@ -0,0 +1,128 @@
+package datastore
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	pathAttribute   = attribute.Key("datastore.path")
+	bytesAttribute  = attribute.Key("datastore.bytes")
+	existsAttribute = attribute.Key("datastore.exists")
+)
+
+// WithTracing decorates the given DataStore so that GetFile, PutFile,
+// PutFileIfNotExists, Exists and Size calls emit a span created by tracer,
+// carrying the path of the file and, except for Exists, its size in bytes.
+// The span of a GetFile call ends when the returned reader is closed, so that
+// it covers the download and records the number of bytes read.
+func WithTracing(dataStore DataStore, tracer trace.Tracer) DataStore {
+	return tracingDataStore{DataStore: dataStore, tracer: tracer}
+}
+
+type tracingDataStore struct {
+	DataStore
+	tracer trace.Tracer
+}
+
+func (t tracingDataStore) start(ctx context.Context, operation, path string) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, "datastore."+operation, trace.WithAttributes(pathAttribute.String(path)))
+}
+
+func (t tracingDataStore) GetFile(ctx context.Context, path string) (io.ReadCloser, error) {
+	ctx, span := t.start(ctx, "GetFile", path)
+	reader, err := t.DataStore.GetFile(ctx, path)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	return &spanEndingReader{ReadCloser: reader, span: span}, nil
+}
+
+func (t tracingDataStore) PutFile(ctx context.Context, path string, in io.WriterTo, metaData map[string]string) error {
+	ctx, span := t.start(ctx, "PutFile", path)
+	counting := &countingWriterTo{WriterTo: in}
+	err := t.DataStore.PutFile(ctx, path, counting, metaData)
+	span.SetAttributes(bytesAttribute.Int64(counting.written))
+	endSpan(span, err)
+	return err
+}
+
+func (t tracingDataStore) PutFileIfNotExists(ctx context.Context, path string, in io.WriterTo, metaData map[string]string) (bool, error) {
+	ctx, span := t.start(ctx, "PutFileIfNotExists", path)
+	counting := &countingWriterTo{WriterTo: in}
+	written, err := t.DataStore.PutFileIfNotExists(ctx, path, counting, metaData)
+	span.SetAttributes(bytesAttribute.Int64(counting.written))
+	endSpan(span, err)
+	return written, err
+}
+
+func (t tracingDataStore) Exists(ctx context.Context, path string) (bool, error) {
+	ctx, span := t.start(ctx, "Exists", path)
+	exists, err := t.DataStore.Exists(ctx, path)
+	if err == nil {
+		span.SetAttributes(existsAttribute.Bool(exists))
+	}
+	endSpan(span, err)
+	return exists, err
+}
+
+func (t tracingDataStore) Size(ctx context.Context, path string) (int64, error) {
+	ctx, span := t.start(ctx, "Size", path)
+	size, err := t.DataStore.Size(ctx, path)
+	if err == nil {
+		span.SetAttributes(bytesAttribute.Int64(size))
+	}
+	endSpan(span, err)
+	return size, err
+}
+
+// endSpan ends span, marking it as failed if err is not nil.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// spanEndingReader counts the bytes read from it and ends its span the first
+// time it is closed.
+type spanEndingReader struct {
+	io.ReadCloser
+	span trace.Span
+	read int64
+	once sync.Once
+}
+
+func (r *spanEndingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	return n, err
+}
+
+func (r *spanEndingReader) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(func() {
+		r.span.SetAttributes(bytesAttribute.Int64(r.read))
+		endSpan(r.span, err)
+	})
+	return err
+}
+
+// countingWriterTo counts the bytes written by the wrapped io.WriterTo.
+type countingWriterTo struct {
+	io.WriterTo
+	written int64
+}
+
+func (c *countingWriterTo) WriteTo(w io.Writer) (int64, error) {
+	n, err := c.WriterTo.WriteTo(w)
+	c.written += n
+	return n, err
+}
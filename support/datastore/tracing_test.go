@@ -0,0 +1,107 @@
+package datastore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordingTracer records the spans it starts in memory.
+type recordingTracer struct {
+	noop.Tracer
+	spans []*recordingSpan
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	config := trace.NewSpanStartConfig(opts...)
+	span := &recordingSpan{name: name, attributes: config.Attributes()}
+	r.spans = append(r.spans, span)
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+type recordingSpan struct {
+	noop.Span
+	name       string
+	attributes []attribute.KeyValue
+	status     codes.Code
+	ended      int
+}
+
+func (s *recordingSpan) SetAttributes(attributes ...attribute.KeyValue) {
+	s.attributes = append(s.attributes, attributes...)
+}
+
+func (s *recordingSpan) SetStatus(code codes.Code, _ string) {
+	s.status = code
+}
+
+func (s *recordingSpan) End(...trace.SpanEndOption) {
+	s.ended++
+}
+
+func TestTracing(t *testing.T) {
+	ctx := context.Background()
+	mockStore := &MockDataStore{}
+	mockStore.On("GetFile", mock.Anything, "a").Return(io.NopCloser(bytes.NewReader([]byte("hello"))), nil).Once()
+	mockStore.On("PutFile", mock.Anything, "b", mock.Anything, map[string]string(nil)).Run(func(args mock.Arguments) {
+		_, err := args.Get(2).(io.WriterTo).WriteTo(io.Discard)
+		require.NoError(t, err)
+	}).Return(nil).Once()
+	mockStore.On("Exists", mock.Anything, "c").Return(true, nil).Once()
+	mockStore.On("Size", mock.Anything, "d").Return(int64(0), errors.New("boom")).Once()
+	tracer := &recordingTracer{}
+	store := WithTracing(mockStore, tracer)
+
+	reader, err := store.GetFile(ctx, "a")
+	require.NoError(t, err)
+	// the GetFile span covers reading the file
+	require.Zero(t, tracer.spans[0].ended)
+	_, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	require.NoError(t, reader.Close())
+
+	require.NoError(t, store.PutFile(ctx, "b", bytes.NewBufferString("abc"), nil))
+	exists, err := store.Exists(ctx, "c")
+	require.NoError(t, err)
+	require.True(t, exists)
+	_, err = store.Size(ctx, "d")
+	require.EqualError(t, err, "boom")
+
+	expected := []recordingSpan{
+		{
+			name:       "datastore.GetFile",
+			attributes: []attribute.KeyValue{pathAttribute.String("a"), bytesAttribute.Int64(5)},
+		},
+		{
+			name:       "datastore.PutFile",
+			attributes: []attribute.KeyValue{pathAttribute.String("b"), bytesAttribute.Int64(3)},
+		},
+		{
+			name:       "datastore.Exists",
+			attributes: []attribute.KeyValue{pathAttribute.String("c"), existsAttribute.Bool(true)},
+		},
+		{
+			name:       "datastore.Size",
+			attributes: []attribute.KeyValue{pathAttribute.String("d")},
+			status:     codes.Error,
+		},
+	}
+	require.Len(t, tracer.spans, len(expected))
+	for i, span := range tracer.spans {
+		require.Equal(t, expected[i].name, span.name)
+		require.Equal(t, expected[i].attributes, span.attributes)
+		require.Equal(t, expected[i].status, span.status)
+		require.Equal(t, 1, span.ended, span.name)
+	}
+	mockStore.AssertExpectations(t)
+}
@@ -1,22 +1,37 @@
 package xdr
 
 import (
+	"errors"
 	"fmt"
 )
 
+// ErrLedgerNotInBatch is returned by LedgerCloseMetaBatch.GetLedger when the
+// batch does not hold the requested ledger.
+var ErrLedgerNotInBatch = errors.New("ledger not in batch")
+
+// ledgerNotInBatchError describes why a ledger is not in a batch while still
+// matching ErrLedgerNotInBatch.
+type ledgerNotInBatchError string
+
+func (e ledgerNotInBatchError) Error() string { return string(e) }
+
+func (e ledgerNotInBatchError) Is(target error) bool { return target == ErrLedgerNotInBatch }
+
 // GetLedger retrieves the LedgerCloseMeta for a given sequence number.
-// It returns an error if LedgerCloseMeta for the sequence number is not found in the batch.
+// It returns an error matching ErrLedgerNotInBatch if LedgerCloseMeta for the
+// sequence number is not found in the batch.
 func (s *LedgerCloseMetaBatch) GetLedger(sequence uint32) (LedgerCloseMeta, error) {
-
+	// Checked before computing the index, which would underflow otherwise
 	if sequence < uint32(s.StartSequence) || sequence > uint32(s.EndSequence) {
-		return LedgerCloseMeta{}, fmt.Errorf("ledger sequence %d is outside the "+
+		return LedgerCloseMeta{}, ledgerNotInBatchError(fmt.Sprintf("ledger sequence %d is outside the "+
 			"valid range of ledger sequences [%d, %d] this batch holds",
-			sequence, s.StartSequence, s.EndSequence)
+			sequence, s.StartSequence, s.EndSequence))
 	}
 
 	ledgerIndex := sequence - uint32(s.StartSequence)
 	if ledgerIndex >= uint32(len(s.LedgerCloseMetas)) {
-		return LedgerCloseMeta{}, fmt.Errorf("LedgerCloseMeta for sequence %d not found in the batch", sequence)
+		return LedgerCloseMeta{}, ledgerNotInBatchError(fmt.Sprintf("LedgerCloseMeta for sequence %d not found in the batch "+
+			"[%d, %d] holding %d ledgers", sequence, s.StartSequence, s.EndSequence, len(s.LedgerCloseMetas)))
 	}
 	return s.LedgerCloseMetas[ledgerIndex], nil
 }
@@ -162,9 +162,17 @@ func TestGetLedger(t *testing.T) {
 				"of ledger sequences [%d, %d] this batch holds", start-1, start, end),
 		},
 		{
-			name:           "LedgerCloseMetaNotFound",
-			ledgerSeq:      end - 5,
-			expectedErrMsg: fmt.Sprintf("LedgerCloseMeta for sequence %d not found in the batch", end-5),
+			name:      "LedgerCloseMetaNotFound",
+			ledgerSeq: end - 5,
+			expectedErrMsg: fmt.Sprintf("LedgerCloseMeta for sequence %d not found in the batch "+
+				"[%d, %d] holding %d ledgers", end-5, start, end, end-10-start+1),
+		},
+		{
+			// the index of the first missing ledger equals len(LedgerCloseMetas)
+			name:      "LedgerCloseMetaTruncated",
+			ledgerSeq: end - 9,
+			expectedErrMsg: fmt.Sprintf("LedgerCloseMeta for sequence %d not found in the batch "+
+				"[%d, %d] holding %d ledgers", end-9, start, end, end-10-start+1),
 		},
 	}
 
@@ -173,6 +181,7 @@ func TestGetLedger(t *testing.T) {
 			archive, err := f.GetLedger(tc.ledgerSeq)
 			if tc.expectedErrMsg != "" {
 				require.EqualError(t, err, tc.expectedErrMsg)
+				require.ErrorIs(t, err, ErrLedgerNotInBatch)
 				require.Equal(t, archive, LedgerCloseMeta{})
 			} else {
 				require.NoError(t, err)
@@ -181,3 +190,15 @@ func TestGetLedger(t *testing.T) {
 		})
 	}
 }
+
+func TestLedgerMetaArchive_GetLedgerBeforeStartSequence(t *testing.T) {
+	// A batch stored under the key of earlier ledgers, whose bounds are also
+	// inconsistent, must not underflow the index of the requested ledger.
+	f := LedgerCloseMetaBatch{StartSequence: 10, EndSequence: 5}
+	for _, sequence := range []uint32{0, 5, 9} {
+		_, err := f.GetLedger(sequence)
+		require.ErrorIs(t, err, ErrLedgerNotInBatch)
+		require.EqualError(t, err, fmt.Sprintf("ledger sequence %d is outside the valid range "+
+			"of ledger sequences [10, 5] this batch holds", sequence))
+	}
+}